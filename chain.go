@@ -0,0 +1,74 @@
+package fault
+
+// Root returns the root cause of the error chain. In other words, the error that
+// was either created with `errors.New` (or similar) or some external error from
+// another library not using Fault. Returns nil if the chain is empty.
+func (c Chain) Root() error {
+	for _, s := range c {
+		if s.err != nil {
+			return s.err
+		}
+	}
+
+	return nil
+}
+
+// Errors returns the list of wrapped errors in the chain where the first item is
+// the wrapper of the root cause. Steps that only represent a location where the
+// error was wrapped are not included as they do not have an error value.
+func (c Chain) Errors() []error {
+	errs := []error{}
+	root := false
+	for _, s := range c {
+		if s.err == nil {
+			continue
+		}
+
+		if !root {
+			root = true
+			continue
+		}
+
+		errs = append(errs, s.err)
+	}
+
+	return errs
+}
+
+// Location returns the outermost location in the chain, which is the location
+// where the error was most recently wrapped. Returns an empty string if none of
+// the steps in the chain have a location.
+func (c Chain) Location() string {
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].Location != "" {
+			return c[i].Location
+		}
+	}
+
+	return ""
+}
+
+// Find returns the first step, starting from the root cause, which satisfies
+// the given function. For example, to find the first step with a message:
+//
+//	step, ok := chain.Find(func(s Step) bool { return s.Message != "" })
+func (c Chain) Find(fn func(Step) bool) (Step, bool) {
+	for _, s := range c {
+		if fn(s) {
+			return s, true
+		}
+	}
+
+	return Step{}, false
+}
+
+// FindLast is the same as Find except it starts from the outermost step.
+func (c Chain) FindLast(fn func(Step) bool) (Step, bool) {
+	for i := len(c) - 1; i >= 0; i-- {
+		if fn(c[i]) {
+			return c[i], true
+		}
+	}
+
+	return Step{}, false
+}
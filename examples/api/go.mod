@@ -8,4 +8,9 @@ require (
 	github.com/go-chi/render v1.0.2
 )
 
-require github.com/ajg/form v1.5.1 // indirect
+require (
+	github.com/ajg/form v1.5.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/Southclaws/fault => ../..
@@ -1,10 +1,9 @@
 package http
 
 import (
-	"context"
-	"fmt"
 	"github.com/Southclaws/fault/fctx"
 	"github.com/Southclaws/fault/fmsg"
+	"github.com/Southclaws/fault/fslog"
 	"github.com/Southclaws/fault/ftag"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -21,7 +20,7 @@ func DecorateRequestMetadata(next http.Handler) http.Handler {
 		ctx = fctx.WithMeta(ctx, "request_id", rid)
 		ctx = fctx.WithMeta(ctx, "http_method", r.Method)
 		ctx = fctx.WithMeta(ctx, "request_path", r.URL.Path)
-		ctx = fctx.WithMeta(ctx, "remote_ip", r.RemoteAddr)
+		ctx = fctx.WithSensitive(ctx, "remote_ip", r.RemoteAddr)
 		ctx = fctx.WithMeta(ctx, "protocol", r.Proto)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
@@ -41,25 +40,16 @@ func PathVariableAsFCtx(pathVarName, fctxName string) func(next http.Handler) ht
 	}
 }
 
-func fctxToSlog(ctx context.Context) []any {
-	faultFields := fctx.GetMeta(ctx)
-	fields := make([]any, 0, len(faultFields))
-	for k, v := range faultFields {
-		fields = append(fields, slog.String(k, v))
-	}
-	return fields
-}
-
 func LoggerRequest(logger *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 			t1 := time.Now()
 			defer func() {
-				fields := fctxToSlog(r.Context())
-				fields = append(fields, slog.Int("status", ww.Status()))
-				fields = append(fields, slog.Duration("latency", time.Since(t1)))
-				logger.Info("API Request", fields...)
+				logger.InfoContext(r.Context(), "API Request",
+					slog.Int("status", ww.Status()),
+					slog.Duration("latency", time.Since(t1)),
+				)
 			}()
 			next.ServeHTTP(ww, r)
 		}
@@ -76,10 +66,7 @@ func RespondWithError(
 ) {
 	tag := ftag.Get(err)
 
-	attrs := fctxToSlog(r.Context())
-	errStr := fmt.Sprintf("%+v", err)
-	attrs = append(attrs, slog.String("error", err.Error()))
-	logger.Error("\n"+errStr, attrs...)
+	logger.ErrorContext(r.Context(), "API Error", fslog.Err(err))
 
 	// Using tags to determine http status based on the error
 	if tag == ftag.NotFound {
@@ -5,6 +5,7 @@ import (
 	"github.com/Southclaws/fault"
 	apihttp "github.com/Southclaws/fault/examples/api/http"
 	"github.com/Southclaws/fault/fmsg"
+	"github.com/Southclaws/fault/fslog"
 	"github.com/Southclaws/fault/ftag"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,7 +19,9 @@ var ErrorUserID = "999"
 var logger *slog.Logger
 
 func init() {
-	logger = slog.Default()
+	// fslog.NewHandler adds the fctx metadata from the request context to each
+	// log entry, so handlers don't need to do it themselves.
+	logger = slog.New(fslog.NewHandler(slog.Default().Handler(), nil))
 	render.Respond = func(w http.ResponseWriter, r *http.Request, v interface{}) {
 		if _, ok := v.(error); ok {
 
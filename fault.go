@@ -7,6 +7,7 @@ package fault
 
 import (
 	"fmt"
+	"io"
 	"runtime"
 	"strings"
 )
@@ -27,8 +28,8 @@ func Wrap(err error, w ...Wrapper) error {
 	// wrapped in a container which will have a location.
 	if _, ok := err.(*container); !ok {
 		err = &container{
-			cause:    err,
-			location: "",
+			cause: err,
+			pc:    0,
 		}
 	}
 
@@ -37,31 +38,56 @@ func Wrap(err error, w ...Wrapper) error {
 	}
 
 	c := &container{
-		cause:    err,
-		location: getLocation(),
+		cause: err,
+		pc:    getPC(),
+		stack: getStack(),
 	}
 
 	return c
 }
 
+// container stores the program counter of the location where it was created.
+// Resolving this to a file and line is deferred until the error is flattened as
+// most errors are handled without ever being printed.
 type container struct {
-	cause    error
-	location string
+	cause error
+	pc    uintptr
+	stack []uintptr
 }
 
 // Error behaves like most error wrapping libraries, it gives you all the error
 // messages conjoined with ": ". This is useful only for internal error reports,
 // never show this to an end-user or include it in responses as it may reveal
 // internal technical information about your application stack.
-func (f *container) Error() string {
+func (f *container) Error() string { return errorMessage(f) }
+
+func (f *container) Unwrap() error { return f.cause }
+
+func (f *container) Format(s fmt.State, verb rune) { format(f, s, verb) }
+
+// errorMessage joins all the messages in the error chain, see container.Error.
+func errorMessage(err error) string {
+	return joinMessages(err, false)
+}
+
+// joinMessages joins all the messages in the error chain, they're redacted
+// unless raw is set.
+func joinMessages(err error, raw bool) string {
 	errs := []string{}
-	chain := Flatten(f)
+
+	var r []Redactor
+	if !raw {
+		r = getRedactors()
+	}
+
+	// locations are not necessary here so the chain is not resolved.
+	chain := flatten(unwind(err), raw)
 
 	// reverse iterate since the chain is in caller order
 	for i := len(chain) - 1; i >= 0; i-- {
 		message := chain[i].Message
 		if message != "" && !isInternalString(message) {
-			errs = append(errs, chain[i].Message)
+			errs = append(errs, redact(r, message))
 		}
 	}
 
@@ -73,38 +99,75 @@ func (f *container) Error() string {
 	return message
 }
 
-func (f *container) Unwrap() error { return f.cause }
-
-func (f *container) Format(s fmt.State, verb rune) {
+func format(err error, s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			u := Flatten(f)
-			for _, v := range u {
-				if v.Message != "" {
-					fmt.Fprintf(s, "%s\n", v.Message)
-				}
-				if v.Location != "" {
-					fmt.Fprintf(s, "\t%s\n", v.Location)
-				}
-			}
+			writeTree(s, FlattenTree(err), "", s.Flag('#'))
 			return
 		}
 
 		fallthrough
 
 	case 's':
-		fmt.Fprint(s, f.Error())
+		fmt.Fprint(s, err.Error())
 	}
 }
 
-func getLocation() string {
-	pc := make([]uintptr, 1)
-	runtime.Callers(3, pc)
-	cf := runtime.CallersFrames(pc)
+// writeTree writes a tree in the same format as a flat chain, branches are
+// written first since they are deeper in the chain and each one is indented.
+// When funcs is set, each location is preceded by its function name in the same
+// style as a panic's stack trace.
+func writeTree(w io.Writer, t *Tree, indent string, funcs bool) {
+	for i, b := range t.Branches {
+		fmt.Fprintf(w, "%s[%d/%d]\n", indent, i+1, len(t.Branches))
+		writeTree(w, &b, indent+"\t", funcs)
+	}
+
+	for _, v := range t.Chain {
+		if v.Message != "" {
+			fmt.Fprintf(w, "%s%s\n", indent, v.Message)
+		}
+
+		stack := v.Stack()
+		if len(stack) == 0 && v.Location != "" {
+			stack = []Frame{{
+				Function: v.Function,
+				Package:  v.Package,
+				File:     v.File,
+				Line:     v.Line,
+			}}
+		}
+
+		for _, f := range stack {
+			if funcs && f.Function != "" {
+				fmt.Fprintf(w, "%s\t%s\n", indent, f.Function)
+				fmt.Fprintf(w, "%s\t\t%s\n", indent, f)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\n", indent, f)
+			}
+		}
+	}
+}
+
+func getPC() uintptr {
+	var pc [1]uintptr
+	if runtime.Callers(3, pc[:]) == 0 {
+		return 0
+	}
+
+	return pc[0]
+}
+
+func getFrame(pc uintptr) (Frame, bool) {
+	if pc == 0 {
+		return Frame{}, false
+	}
+
+	cf := runtime.CallersFrames([]uintptr{pc})
 	f, _ := cf.Next()
 
-	return fmt.Sprintf("%s:%d", f.File, f.Line)
+	return newFrame(f), true
 }
 
 // isInternalString returns true for messages like <fctx> which are placeholders
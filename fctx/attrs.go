@@ -0,0 +1,76 @@
+package fctx
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// WithAttrs is the same as `WithMeta` except the metadata is provided as typed
+// slog attributes instead of strings. This allows values such as numbers and
+// durations to keep their types when they are logged.
+//
+//	ctx = fctx.WithAttrs(ctx,
+//		slog.Int("retries", retries),
+//		slog.Duration("timeout", timeout),
+//	)
+//
+// Typed values are still available from `GetMeta` and `Unwrap` as strings.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if ctx == nil {
+		return nil
+	}
+
+	return context.WithValue(ctx, contextKey{}, extend(ctx, false, attrs...))
+}
+
+// WrapAttrs is the same as `Wrap` except the additional metadata is provided as
+// typed slog attributes instead of strings.
+func WrapAttrs(err error, ctx context.Context, attrs ...slog.Attr) error {
+	if err == nil || ctx == nil {
+		return err
+	}
+
+	return &withContext{err, extend(ctx, false, attrs...)}
+}
+
+// UnwrapAttrs is the same as `Unwrap` except the metadata is returned as typed
+// slog attributes, sorted by key.
+func UnwrapAttrs(err error) []slog.Attr {
+	return attrs(unwrap(err, getRedaction()))
+}
+
+// GetAttrs is the same as `GetMeta` except the metadata is returned as typed
+// slog attributes, sorted by key.
+func GetAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	return attrs(getNode(ctx).redacted(getRedaction()))
+}
+
+func attrs(meta map[string]slog.Value) []slog.Attr {
+	if len(meta) == 0 {
+		return nil
+	}
+
+	keys := sortedKeys(meta)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Attr{Key: k, Value: valueAttr(meta[k])})
+	}
+
+	return attrs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -1,6 +1,7 @@
 // Package fctx facilitates storing simple string based key-value data into
 // contexts and then wrapping error values with that data so top-level error
-// handlers have access to the data from the entire call chain.
+// handlers have access to the data from the entire call chain. Typed values can
+// also be stored as slog attributes using `WithAttrs`.
 //
 // You can call `WithMeta` as many times as you like during a chain of function
 // calls to decorate that call chain with metadata such as user IDs, request IDs
@@ -13,6 +14,7 @@ package fctx
 import (
 	"context"
 	"errors"
+	"log/slog"
 )
 
 type contextKey struct{}
@@ -20,7 +22,7 @@ type contextKey struct{}
 // withContext implements the error interface and stores a simple table of data.
 type withContext struct {
 	underlying error
-	meta       map[string]string
+	node       *node
 }
 
 func (e *withContext) Error() string  { return "<fctx>" }
@@ -49,7 +51,7 @@ func WithMeta(ctx context.Context, kv ...string) context.Context {
 	}
 
 	// overwrite any existing context metadata
-	return context.WithValue(ctx, contextKey{}, createMeta(ctx, kv...))
+	return context.WithValue(ctx, contextKey{}, extend(ctx, false, pairs(kv)...))
 }
 
 // Wrap wraps an error with the metadata stored in the context using `WithMeta`.
@@ -77,32 +79,25 @@ func Wrap(err error, ctx context.Context, kv ...string) error {
 		return err
 	}
 
-	return &withContext{err, createMeta(ctx, kv...)}
+	return &withContext{err, extend(ctx, false, pairs(kv)...)}
 }
 
-func createMeta(ctx context.Context, kv ...string) map[string]string {
-	meta := make(map[string]string)
-
-	if parent, ok := ctx.Value(contextKey{}).(map[string]string); ok {
-		// make a copy to avoid mutating parent context meta via map reference.
-		for k, v := range parent {
-			meta[k] = v
-		}
-	}
-
+// pairs converts a list of key-value strings into attributes.
+func pairs(kv []string) []slog.Attr {
 	l := len(kv)
 	if l%2 != 0 {
 		l -= 1 // don't error on odd number of args
 	}
 
+	attrs := make([]slog.Attr, 0, l/2)
 	for i := 0; i < l; i += 2 {
 		k := kv[i]
 		v := kv[i+1]
 
-		meta[k] = v
+		attrs = append(attrs, slog.String(k, v))
 	}
 
-	return meta
+	return attrs
 }
 
 // With implements the Fault Wrapper interface.
@@ -137,13 +132,22 @@ func With(ctx context.Context, kv ...string) func(error) error {
 //		})
 //	}
 func Unwrap(err error) map[string]string {
-	values := map[string]string{}
+	return stringify(unwrap(err, getRedaction()))
+}
+
+func unwrap(err error, r Redaction) map[string]slog.Value {
+	var values map[string]slog.Value
 
 	for err != nil {
 		if f, ok := err.(*withContext); ok {
-			if m := f.meta; m != nil {
-				for k, v := range m {
-					values[k] = v
+			if m := f.node.redacted(r); m != nil {
+				// the first map is a fresh copy so it can be used as is.
+				if values == nil {
+					values = m
+				} else {
+					for k, v := range m {
+						values[k] = v
+					}
 				}
 			}
 		}
@@ -191,10 +195,19 @@ func GetMeta(ctx context.Context) map[string]string {
 		return nil
 	}
 
-	meta, ok := ctx.Value(contextKey{}).(map[string]string)
-	if !ok {
+	return stringify(getNode(ctx).redacted(getRedaction()))
+}
+
+// stringify converts typed metadata to strings for the string based API.
+func stringify(meta map[string]slog.Value) map[string]string {
+	if meta == nil {
 		return nil
 	}
 
-	return meta
+	values := make(map[string]string, len(meta))
+	for k, v := range meta {
+		values[k] = valueString(v)
+	}
+
+	return values
 }
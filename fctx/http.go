@@ -0,0 +1,255 @@
+package fctx
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BaggageHeader is the W3C Baggage header used to propagate metadata over HTTP.
+//
+// https://www.w3.org/TR/baggage/
+const BaggageHeader = "baggage"
+
+// The limits of a baggage header from the W3C specification.
+const (
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+// BaggageOptions are options for the HTTP baggage integration. A zero
+// BaggageOptions consists entirely of default values.
+type BaggageOptions struct {
+	// Allow, if set, limits the keys which are read from and written to the
+	// baggage header to only these keys. Otherwise, every key is propagated so
+	// make sure your metadata doesn't contain anything you wouldn't want to send
+	// to other services. Always set it for endpoints which receive requests
+	// from outside of your own services, since clients can send any key.
+	Allow []string
+
+	// Deny lists keys which are never read from or written to the baggage
+	// header, even if they are in Allow.
+	Deny []string
+}
+
+func (o *BaggageOptions) allowed(key string) bool {
+	if o == nil {
+		return true
+	}
+
+	for _, k := range o.Deny {
+		if k == key {
+			return false
+		}
+	}
+
+	if len(o.Allow) == 0 {
+		return true
+	}
+
+	for _, k := range o.Allow {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Middleware returns HTTP middleware that reads the metadata from the baggage
+// header of incoming requests and stores it in the request's context using
+// `WithMeta`, so any errors wrapped further down the call chain will include the
+// metadata from the service that made the request.
+//
+//	router.Use(fctx.Middleware(&fctx.BaggageOptions{
+//		Allow: []string{"request_id", "tenant_id"},
+//	}))
+//
+// Only the first 180 members or 8192 bytes of baggage are read, which are the
+// limits of the specification. Baggage never replaces metadata which is already
+// in the request's context, so values set by your own middleware, such as a
+// request ID, can't be overwritten by a client.
+//
+// If opts is nil, the default options are used and every key is read, the same
+// as `Transport` writes every key. This suits requests between your own
+// services. Set Allow for endpoints which are reachable by anyone else so that
+// clients can only add the keys you expect.
+func Middleware(opts *BaggageOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if headers := r.Header.Values(BaggageHeader); len(headers) > 0 {
+				ctx := r.Context()
+				if kv := parseBaggage(headers, opts, getNode(ctx).meta()); len(kv) > 0 {
+					r = r.WithContext(WithMeta(ctx, kv...))
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Transport returns an http.RoundTripper that writes the metadata stored in the
+// context of each outgoing request into its baggage header before passing the
+// request on to next. If next is nil, `http.DefaultTransport` is used.
+//
+//	client := &http.Client{
+//		Transport: fctx.Transport(nil, &fctx.BaggageOptions{
+//			Allow: []string{"request_id", "tenant_id"},
+//		}),
+//	}
+//
+// Sensitive metadata, see `WithSensitive`, is never written. Members of an
+// existing baggage header are kept unless they have the same key as some
+// metadata. If opts is nil, the default options are used.
+func Transport(next http.RoundTripper, opts *BaggageOptions) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{next, opts}
+}
+
+type transport struct {
+	next http.RoundTripper
+	opts *BaggageOptions
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	meta := stringify(getNode(r.Context()).redacted(Omitted))
+	if len(meta) == 0 {
+		return t.next.RoundTrip(r)
+	}
+
+	members := []string{}
+	for _, k := range sortedKeys(meta) {
+		if !isToken(k) || !t.opts.allowed(k) {
+			continue
+		}
+
+		members = append(members, k+"="+url.PathEscape(meta[k]))
+	}
+
+	for _, m := range splitBaggage(r.Header.Values(BaggageHeader)) {
+		if k, _, ok := parseMember(m); ok {
+			if _, exists := meta[k]; exists && t.opts.allowed(k) {
+				continue
+			}
+		}
+
+		members = append(members, m)
+	}
+
+	// RoundTrippers must not modify the request so a copy is sent instead.
+	r = r.Clone(r.Context())
+	r.Header.Del(BaggageHeader)
+	if header := joinBaggage(members); header != "" {
+		r.Header.Set(BaggageHeader, header)
+	}
+
+	return t.next.RoundTrip(r)
+}
+
+// parseBaggage returns the allowed members of baggage headers as a list of
+// key-value pairs. Invalid members and members with a key which already exists
+// are skipped and anything beyond the limits of the specification is ignored.
+func parseBaggage(headers []string, opts *BaggageOptions, existing map[string]slog.Value) []string {
+	kv := []string{}
+	size := 0
+
+	for i, m := range splitBaggage(headers) {
+		size += len(m) + 1
+		if i == maxBaggageMembers || size-1 > maxBaggageBytes {
+			break
+		}
+
+		k, v, ok := parseMember(m)
+		if !ok || !opts.allowed(k) {
+			continue
+		}
+
+		if _, exists := existing[k]; exists {
+			continue
+		}
+
+		kv = append(kv, k, v)
+	}
+
+	return kv
+}
+
+// splitBaggage splits baggage headers into trimmed, non-empty list members.
+func splitBaggage(headers []string) []string {
+	members := []string{}
+
+	for _, h := range headers {
+		for _, m := range strings.Split(h, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				members = append(members, m)
+			}
+		}
+	}
+
+	return members
+}
+
+// parseMember parses a "key=value;property" list member, properties are ignored.
+func parseMember(m string) (string, string, bool) {
+	m, _, _ = strings.Cut(m, ";")
+
+	k, v, ok := strings.Cut(m, "=")
+	if !ok {
+		return "", "", false
+	}
+
+	k = strings.TrimSpace(k)
+	if !isToken(k) {
+		return "", "", false
+	}
+
+	v, err := url.PathUnescape(strings.TrimSpace(v))
+	if err != nil {
+		return "", "", false
+	}
+
+	return k, v, true
+}
+
+// joinBaggage joins list members into a header value, stopping once the limits
+// of the specification would be exceeded.
+func joinBaggage(members []string) string {
+	b := strings.Builder{}
+
+	for i, m := range members {
+		if i == maxBaggageMembers || b.Len()+len(m)+1 > maxBaggageBytes {
+			break
+		}
+
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(m)
+	}
+
+	return b.String()
+}
+
+// isToken reports whether a key is a valid RFC 7230 token, as required for keys
+// of the baggage header.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,142 @@
+package fctx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Southclaws/fault"
+)
+
+// Layer is the metadata from a single wrap of an error chain along with the step
+// of the chain where the metadata was attached. When `Wrap` was used directly,
+// rather than via `fault.Wrap`, the step will not have a location.
+type Layer struct {
+	Step fault.Step
+	Meta map[string]string
+
+	// Restored is true if any of the metadata came from a context which was
+	// restored from a snapshot, see `Restore`.
+	Restored bool
+}
+
+// Layers returns the metadata of each wrap in an error chain individually, in
+// order starting from the outermost wrap. Unlike `Unwrap`, metadata is not
+// merged so you can see exactly which values were present at each location.
+func Layers(err error) []Layer {
+	var layers []Layer
+	chain := fault.Flatten(err)
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		f, ok := e.(*withContext)
+		if !ok {
+			continue
+		}
+
+		// The steps of the chain beneath this error are the same as the first
+		// steps of the full chain, so the last one is this error's step. The
+		// full chain is used since it contains the location of the wrap.
+		sub := fault.Flatten(f)
+		step := sub[len(sub)-1]
+		if i := len(sub) - 1; i < len(chain) && chain[i].Message == step.Message {
+			step = chain[i]
+		}
+
+		layers = append(layers, Layer{
+			Step:     step,
+			Meta:     stringify(f.node.redacted(getRedaction())),
+			Restored: f.node.isRestored(),
+		})
+	}
+
+	return layers
+}
+
+// Policy decides which value is used by `UnwrapWith` when the same key has a
+// different value in multiple layers of an error chain.
+type Policy int
+
+const (
+	// InnermostWins uses the value from the wrap closest to the root cause of
+	// the error. This is the policy used by `Unwrap`.
+	InnermostWins Policy = iota
+
+	// OutermostWins uses the value from the wrap furthest from the root cause.
+	OutermostWins
+
+	// KeepAll stores the innermost value under the original key and each other
+	// distinct value under the key with a numeric suffix, starting from 2 and
+	// increasing towards the outermost wrap. For example: "user_id" for the
+	// innermost value and "user_id_2" for a different value from an outer wrap.
+	// Suffixes which are already used by another key are skipped so existing
+	// values are never replaced.
+	KeepAll
+)
+
+// UnwrapWith is the same as `Unwrap` except that conflicting keys are handled
+// according to the given policy.
+func UnwrapWith(err error, p Policy) map[string]string {
+	layers := Layers(err)
+	values := map[string]string{}
+
+	switch p {
+	case OutermostWins:
+		for i := len(layers) - 1; i >= 0; i-- {
+			for k, v := range layers[i].Meta {
+				values[k] = v
+			}
+		}
+
+	case KeepAll:
+		for _, l := range layers {
+			for k, v := range l.Meta {
+				values[k] = v
+			}
+		}
+
+		seen := map[string]map[string]bool{}
+		next := map[string]int{}
+		for k, v := range values {
+			seen[k] = map[string]bool{v: true}
+		}
+
+		for i := len(layers) - 1; i >= 0; i-- {
+			for _, k := range sortedKeys(layers[i].Meta) {
+				v := layers[i].Meta[k]
+				if seen[k][v] {
+					continue
+				}
+				seen[k][v] = true
+
+				// suffixes which are already keys of their own are skipped.
+				n := len(seen[k])
+				if next[k] > n {
+					n = next[k]
+				}
+				key := fmt.Sprintf("%s_%d", k, n)
+				for {
+					if _, used := values[key]; !used {
+						break
+					}
+					n++
+					key = fmt.Sprintf("%s_%d", k, n)
+				}
+				next[k] = n + 1
+
+				values[key] = v
+			}
+		}
+
+	default:
+		for _, l := range layers {
+			for k, v := range l.Meta {
+				values[k] = v
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
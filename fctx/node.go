@@ -0,0 +1,116 @@
+package fctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// node is a single key-value pair of metadata linked to the metadata that was
+// already present when it was added. Nodes are never modified so contexts and
+// errors can share their parent's nodes, which means adding metadata does not
+// copy it. The values are only collected into a map when they are read.
+type node struct {
+	parent    *node
+	key       string
+	value     slog.Value
+	appended  bool
+	restored  bool // see Restore
+	sensitive bool // see WithSensitive
+}
+
+// getNode returns the most recently added metadata stored in a context.
+func getNode(ctx context.Context) *node {
+	n, _ := ctx.Value(contextKey{}).(*node)
+	return n
+}
+
+// extend links each attribute onto the metadata of a context. If appended is
+// set, the values are added to existing keys instead of replacing them.
+func extend(ctx context.Context, appended bool, attrs ...slog.Attr) *node {
+	n := getNode(ctx)
+
+	for _, a := range attrs {
+		if a.Key == "" {
+			continue
+		}
+
+		n = &node{
+			parent:   n,
+			key:      a.Key,
+			value:    a.Value.Resolve(),
+			appended: appended,
+		}
+	}
+
+	return n
+}
+
+// meta materialises the metadata into a new map. A nil node has no metadata and
+// returns a nil map.
+func (n *node) meta() map[string]slog.Value {
+	meta, _ := n.collect()
+	return meta
+}
+
+// collect materialises the metadata into a new map along with the keys which
+// were marked as sensitive with `WithSensitive`, nil if there are none. The
+// nodes are walked starting from the most recently added so that the first
+// value seen for a key is the one that is used.
+func (n *node) collect() (map[string]slog.Value, map[string]bool) {
+	if n == nil {
+		return nil, nil
+	}
+
+	size := 0
+	for p := n; p != nil; p = p.parent {
+		size++
+	}
+
+	meta := make(map[string]slog.Value, size)
+	var sensitive map[string]bool
+
+	// keys which have had values appended are open until a node which set the
+	// value is found, their values are collected newest first.
+	var open map[string]bool
+	var multis []string
+
+	for p := n; p != nil; p = p.parent {
+		if p.sensitive {
+			if sensitive == nil {
+				sensitive = make(map[string]bool)
+			}
+			sensitive[p.key] = true
+		}
+
+		existing, seen := meta[p.key]
+
+		switch {
+		case !seen && !p.appended:
+			meta[p.key] = p.value
+
+		case !seen:
+			if open == nil {
+				open = make(map[string]bool)
+			}
+			open[p.key] = true
+			meta[p.key] = slog.AnyValue(values{p.value})
+			multis = append(multis, p.key)
+
+		case open[p.key]:
+			vs, _ := multi(existing)
+			meta[p.key] = slog.AnyValue(append(vs, p.value))
+			if !p.appended {
+				delete(open, p.key)
+			}
+		}
+	}
+
+	for _, k := range multis {
+		vs, _ := multi(meta[k])
+		for i, j := 0, len(vs)-1; i < j; i, j = i+1, j-1 {
+			vs[i], vs[j] = vs[j], vs[i]
+		}
+	}
+
+	return meta, sensitive
+}
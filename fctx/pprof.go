@@ -0,0 +1,56 @@
+package fctx
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+)
+
+// Do calls f with a copy of ctx which has profiler labels for the metadata
+// stored in ctx, so samples from a CPU profile can be grouped by the metadata,
+// such as by tenant or endpoint. It works in the same way as `pprof.Do`, which
+// means the labels are also applied to any goroutines started by f.
+//
+//	fctx.Do(ctx, func(ctx context.Context) {
+//		process(ctx, job)
+//	}, "tenant_id", "job_type")
+//
+// If keys are provided, only those keys are used as labels. Otherwise, all of
+// the metadata is used. Sensitive metadata, see `WithSensitive`, is never used
+// since profiles are usually shared more widely than logs.
+func Do(ctx context.Context, f func(context.Context), keys ...string) {
+	pprof.Do(ctx, labels(ctx, keys), f)
+}
+
+// Profile returns HTTP middleware which calls the next handler using `Do`, so
+// each request is profiled with labels from the metadata stored in its context.
+// The labels only contain metadata which was added before this middleware, so
+// it should come after any middleware which adds metadata.
+//
+//	router.Use(fctx.Profile("tenant_id", "route"))
+func Profile(keys ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Do(r.Context(), func(ctx context.Context) {
+				next.ServeHTTP(w, r.WithContext(ctx))
+			}, keys...)
+		})
+	}
+}
+
+func labels(ctx context.Context, keys []string) pprof.LabelSet {
+	meta := stringify(getNode(ctx).redacted(Omitted))
+
+	if len(keys) == 0 {
+		keys = sortedKeys(meta)
+	}
+
+	kv := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		if v, ok := meta[k]; ok {
+			kv = append(kv, k, v)
+		}
+	}
+
+	return pprof.Labels(kv...)
+}
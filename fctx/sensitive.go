@@ -0,0 +1,164 @@
+package fctx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Redaction decides how the values of sensitive metadata are returned.
+type Redaction int32
+
+const (
+	// Masked replaces sensitive values with "****". This is the default.
+	Masked Redaction = iota
+
+	// Hashed replaces sensitive values with the start of their SHA-256 hash so
+	// the same value can still be correlated across log entries. Hashes are not
+	// salted so values which are easy to guess, such as IP addresses, may be
+	// recovered from their hash.
+	Hashed
+
+	// Raw returns sensitive values unchanged.
+	Raw
+
+	// Omitted leaves sensitive metadata out entirely. This is always used when
+	// metadata leaves the process, such as by `Snapshot` and `Transport`, since
+	// it can no longer be redacted once it's somewhere else.
+	Omitted
+)
+
+const maskedValue = "****"
+
+var (
+	redaction     int32
+	sensitiveKeys atomic.Value // map[string]bool
+)
+
+// SetRedaction sets how sensitive metadata is returned by `GetMeta`, `Unwrap`
+// and every other function which reads metadata. Use `GetMetaRedacted` and
+// `UnwrapRedacted` to read metadata with a different redaction.
+func SetRedaction(r Redaction) {
+	atomic.StoreInt32(&redaction, int32(r))
+}
+
+func getRedaction() Redaction {
+	return Redaction(atomic.LoadInt32(&redaction))
+}
+
+// SetSensitiveKeys sets a list of keys which are always sensitive, no matter how
+// they were added. This allows personal data to be redacted without auditing
+// every place that metadata is added. Calling it again replaces the list.
+//
+//	fctx.SetSensitiveKeys("email", "remote_ip", "token")
+func SetSensitiveKeys(keys ...string) {
+	m := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		m[k] = true
+	}
+
+	sensitiveKeys.Store(m)
+}
+
+// WithSensitive is the same as `WithMeta` except the metadata is marked as
+// sensitive, so it's redacted when it's read.
+//
+//	ctx = fctx.WithSensitive(ctx, "email", user.Email)
+//
+// Once a key is sensitive, it stays sensitive even if it's set again later with
+// `WithMeta`.
+func WithSensitive(ctx context.Context, kv ...string) context.Context {
+	return WithSensitiveAttrs(ctx, pairs(kv)...)
+}
+
+// WithSensitiveAttrs is the same as `WithSensitive` except the metadata is
+// provided as typed slog attributes instead of strings.
+func WithSensitiveAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if ctx == nil {
+		return nil
+	}
+
+	n := getNode(ctx)
+	for _, a := range attrs {
+		if a.Key == "" {
+			continue
+		}
+
+		n = &node{
+			parent:    n,
+			key:       a.Key,
+			value:     a.Value.Resolve(),
+			sensitive: true,
+		}
+	}
+
+	return context.WithValue(ctx, contextKey{}, n)
+}
+
+// GetMetaRedacted is the same as `GetMeta` except sensitive metadata is redacted
+// using r instead of the redaction set with `SetRedaction`.
+func GetMetaRedacted(ctx context.Context, r Redaction) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+
+	return stringify(getNode(ctx).redacted(r))
+}
+
+// UnwrapRedacted is the same as `Unwrap` except sensitive metadata is redacted
+// using r instead of the redaction set with `SetRedaction`.
+func UnwrapRedacted(err error, r Redaction) map[string]string {
+	return stringify(unwrap(err, r))
+}
+
+// UnwrapValuesRedacted is the same as `UnwrapValues` except sensitive metadata
+// is redacted using r instead of the redaction set with `SetRedaction`.
+func UnwrapValuesRedacted(err error, r Redaction) map[string][]string {
+	return stringifyAll(unwrap(err, r))
+}
+
+// redacted materialises the metadata and redacts the value of every key which is
+// sensitive, either because it was marked as sensitive or it's a global key.
+func (n *node) redacted(r Redaction) map[string]slog.Value {
+	meta, marked := n.collect()
+	if r == Raw || meta == nil {
+		return meta
+	}
+
+	global, _ := sensitiveKeys.Load().(map[string]bool)
+	for _, keys := range []map[string]bool{global, marked} {
+		for k := range keys {
+			v, ok := meta[k]
+			if !ok {
+				continue
+			}
+
+			if r == Omitted {
+				delete(meta, k)
+			} else {
+				meta[k] = redact(v, r)
+			}
+		}
+	}
+
+	return meta
+}
+
+func redact(v slog.Value, r Redaction) slog.Value {
+	if vs, ok := multi(v); ok {
+		redacted := make(values, len(vs))
+		for i, v := range vs {
+			redacted[i] = redact(v, r)
+		}
+		return slog.AnyValue(redacted)
+	}
+
+	if r == Hashed {
+		sum := sha256.Sum256([]byte(v.String()))
+		return slog.StringValue("sha256:" + hex.EncodeToString(sum[:8]))
+	}
+
+	return slog.StringValue(maskedValue)
+}
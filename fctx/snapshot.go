@@ -0,0 +1,114 @@
+package fctx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+)
+
+// Snapshot encodes the metadata stored in a context as a compact JSON object so
+// it can outlive the context, for example by being stored alongside a job in a
+// queue. Use `Restore` to add the metadata to a new context when the job runs.
+//
+//	job.Meta = fctx.Snapshot(ctx)
+//
+// Keys with multiple values, see `Append`, are encoded as a list. Typed values
+// are encoded as strings. Sensitive metadata, see `WithSensitive`, is left out
+// no matter which redaction is set so it's never stored outside of the process.
+// If the context has no metadata, nil is returned.
+func Snapshot(ctx context.Context) []byte {
+	if ctx == nil {
+		return nil
+	}
+
+	meta := stringifyAll(getNode(ctx).redacted(Omitted))
+	if len(meta) == 0 {
+		return nil
+	}
+
+	values := make(map[string]any, len(meta))
+	for k, v := range meta {
+		if len(v) == 1 {
+			values[k] = v[0]
+		} else {
+			values[k] = v
+		}
+	}
+
+	// a map of strings and lists of strings can always be encoded.
+	b, _ := json.Marshal(values)
+	return b
+}
+
+// Restore adds the metadata from a `Snapshot` to a context. Restored metadata is
+// marked so errors wrapped with it can be identified with `IsRestored`.
+//
+//	ctx, err := fctx.Restore(ctx, job.Meta)
+//	if err != nil {
+//		return err
+//	}
+//
+// If data is empty, the context is returned as is.
+func Restore(ctx context.Context, data []byte) (context.Context, error) {
+	if ctx == nil || len(data) == 0 {
+		return ctx, nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return ctx, err
+	}
+
+	n := getNode(ctx)
+	for _, k := range sortedKeys(values) {
+		if k == "" {
+			continue
+		}
+
+		var list []string
+
+		var s string
+		if err := json.Unmarshal(values[k], &s); err == nil {
+			list = []string{s}
+		} else if err := json.Unmarshal(values[k], &list); err != nil {
+			return ctx, err
+		}
+
+		for i, v := range list {
+			n = &node{
+				parent:   n,
+				key:      k,
+				value:    slog.StringValue(v),
+				appended: i > 0,
+				restored: true,
+			}
+		}
+	}
+
+	return context.WithValue(ctx, contextKey{}, n), nil
+}
+
+// IsRestored returns true if any of the metadata in an error chain came from a
+// context which was restored with `Restore`.
+func IsRestored(err error) bool {
+	for err != nil {
+		if f, ok := err.(*withContext); ok && f.node.isRestored() {
+			return true
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return false
+}
+
+func (n *node) isRestored() bool {
+	for ; n != nil; n = n.parent {
+		if n.restored {
+			return true
+		}
+	}
+
+	return false
+}
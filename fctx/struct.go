@@ -0,0 +1,195 @@
+package fctx
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Metadata can be implemented by types to provide their own metadata to
+// `WithStruct` instead of using struct tags.
+type Metadata interface {
+	Metadata() map[string]string
+}
+
+var metadataType = reflect.TypeOf((*Metadata)(nil)).Elem()
+
+// WithStruct is the same as `WithAttrs` except the metadata is read from the
+// fields of a struct which have a `fctx` tag, which saves repeating the same keys
+// every time a domain type is added to a context:
+//
+//	type User struct {
+//		ID    string `fctx:"user_id"`
+//		OrgID string `fctx:"org_id"`
+//		Email string `fctx:"email,omitempty"`
+//		Name  string // not added
+//	}
+//
+//	ctx = fctx.WithStruct(ctx, user)
+//
+// The `omitempty` option skips fields with a zero value and fields tagged with
+// `fctx:"-"` are always skipped. Fields which are structs containing `fctx` tags
+// are added too. If the field has a tag, its name is used as a prefix for the
+// keys of the nested struct, joined with an underscore. Nil pointers are
+// skipped. Values keep their types, as with `WithAttrs`.
+//
+// If v, or a field of v, implements `Metadata`, the map it returns is used
+// instead. The fields of each type are only inspected once and cached.
+func WithStruct(ctx context.Context, v any) context.Context {
+	if ctx == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return ctx
+	}
+
+	attrs := structAttrs(nil, "", rv, planFor(rv.Type()))
+	if len(attrs) == 0 {
+		return ctx
+	}
+
+	return WithAttrs(ctx, attrs...)
+}
+
+// plan describes how metadata is read from a type.
+type plan struct {
+	metadata bool    // the type implements Metadata
+	fields   []field // the tagged fields of a struct type
+}
+
+type field struct {
+	index     int
+	key       string
+	omitempty bool
+	nested    *plan // set if the field is a struct with tagged fields
+}
+
+var plans sync.Map // map[reflect.Type]*plan
+
+func planFor(t reflect.Type) *plan {
+	if p, ok := plans.Load(t); ok {
+		return p.(*plan)
+	}
+
+	p := buildPlan(t, map[reflect.Type]bool{})
+	plans.Store(t, p)
+
+	return p
+}
+
+// buildPlan inspects the fields of a type, the types being inspected are tracked
+// so recursive types do not recurse forever.
+func buildPlan(t reflect.Type, seen map[reflect.Type]bool) *plan {
+	if t.Implements(metadataType) {
+		return &plan{metadata: true}
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return buildPlan(t.Elem(), seen)
+	}
+
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+
+	seen[t] = true
+	defer delete(seen, t)
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag, tagged := sf.Tag.Lookup("fctx")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		f := field{
+			index:     i,
+			key:       name,
+			omitempty: opts == "omitempty",
+		}
+
+		if nested := buildPlan(sf.Type, seen); nested != nil {
+			f.nested = nested
+		} else if !tagged || name == "" {
+			continue
+		}
+
+		fields = append(fields, f)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &plan{fields: fields}
+}
+
+func structAttrs(attrs []slog.Attr, prefix string, v reflect.Value, p *plan) []slog.Attr {
+	if p == nil {
+		return attrs
+	}
+
+	if p.metadata {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return attrs
+		}
+
+		meta := v.Interface().(Metadata).Metadata()
+		for _, k := range sortedKeys(meta) {
+			attrs = append(attrs, slog.String(join(prefix, k), meta[k]))
+		}
+
+		return attrs
+	}
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return attrs
+		}
+		v = v.Elem()
+	}
+
+	for _, f := range p.fields {
+		fv := v.Field(f.index)
+		if f.omitempty && fv.IsZero() {
+			continue
+		}
+
+		if f.nested != nil {
+			attrs = structAttrs(attrs, join(prefix, f.key), fv, f.nested)
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+
+		attrs = append(attrs, slog.Any(join(prefix, f.key), fv.Interface()))
+	}
+
+	return attrs
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if key == "" {
+		return prefix
+	}
+
+	return prefix + "_" + key
+}
@@ -0,0 +1,114 @@
+package fctx
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// values holds every value of a key that has been added with `Append`.
+type values []slog.Value
+
+// Append adds values to keys in a context instead of overwriting them, which is
+// useful for metadata that accumulates during a call chain such as feature flags
+// that were checked or shards that were queried:
+//
+//	ctx = fctx.Append(ctx, "shard", "eu-1")
+//	ctx = fctx.Append(ctx, "shard", "eu-2")
+//
+// Appending to a key which was set with `WithMeta` keeps the existing value as
+// the first value. Setting a key with `WithMeta` after appending to it replaces
+// all of the values.
+//
+// All values are available in order from `GetMetaValues` and `UnwrapValues`.
+// `GetMeta` and `Unwrap` join the values with a comma.
+func Append(ctx context.Context, kv ...string) context.Context {
+	return AppendAttrs(ctx, pairs(kv)...)
+}
+
+// AppendAttrs is the same as `Append` except the values are provided as typed
+// slog attributes instead of strings.
+func AppendAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if ctx == nil {
+		return nil
+	}
+
+	return context.WithValue(ctx, contextKey{}, extend(ctx, true, attrs...))
+}
+
+// GetMetaValues is the same as `GetMeta` except every value of keys added with
+// `Append` is returned in the order they were added. Other keys have one value.
+func GetMetaValues(ctx context.Context) map[string][]string {
+	if ctx == nil {
+		return nil
+	}
+
+	return stringifyAll(getNode(ctx).redacted(getRedaction()))
+}
+
+// UnwrapValues is the same as `Unwrap` except every value of keys added with
+// `Append` is returned in the order they were added. Other keys have one value.
+func UnwrapValues(err error) map[string][]string {
+	return stringifyAll(unwrap(err, getRedaction()))
+}
+
+func multi(v slog.Value) (values, bool) {
+	if v.Kind() != slog.KindAny {
+		return nil, false
+	}
+
+	vs, ok := v.Any().(values)
+	return vs, ok
+}
+
+// valueString formats a value for the string based API.
+func valueString(v slog.Value) string {
+	vs, ok := multi(v)
+	if !ok {
+		return v.String()
+	}
+
+	s := make([]string, len(vs))
+	for i, v := range vs {
+		s[i] = v.String()
+	}
+
+	return strings.Join(s, ",")
+}
+
+// valueAttr converts a value for the slog based API, multiple values become a
+// slice so handlers render them as a list.
+func valueAttr(v slog.Value) slog.Value {
+	vs, ok := multi(v)
+	if !ok {
+		return v
+	}
+
+	list := make([]any, len(vs))
+	for i, v := range vs {
+		list[i] = v.Any()
+	}
+
+	return slog.AnyValue(list)
+}
+
+func stringifyAll(meta map[string]slog.Value) map[string][]string {
+	if meta == nil {
+		return nil
+	}
+
+	all := make(map[string][]string, len(meta))
+	for k, v := range meta {
+		if vs, ok := multi(v); ok {
+			s := make([]string, len(vs))
+			for i, v := range vs {
+				s[i] = v.String()
+			}
+			all[k] = s
+		} else {
+			all[k] = []string{v.String()}
+		}
+	}
+
+	return all
+}
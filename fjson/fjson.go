@@ -0,0 +1,228 @@
+// Package fjson encodes errors into a single JSON document which contains the
+// error message, the flattened error chain and optionally the data stored by
+// the other Fault utilities such as tags, metadata and end-user issues. This is
+// useful for structured logging pipelines and debugging payloads in responses.
+//
+// Documents can also be decoded back into an error, which allows error chains
+// to cross service boundaries. For example, an HTTP API can respond with the
+// document and the client can decode it and wrap it like any other error.
+package fjson
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fctx"
+	"github.com/Southclaws/fault/fmsg"
+	"github.com/Southclaws/fault/ftag"
+)
+
+// Document is the JSON representation of an error chain.
+type Document struct {
+	Message string                       `json:"message"`
+	Chain   fault.Chain                  `json:"chain"`
+	Tags    []ftag.Kind                  `json:"tags,omitempty"`
+	Meta    Meta                         `json:"meta,omitempty"`
+	Issues  []fmsg.Issue                 `json:"issues,omitempty"`
+	Details []fmsg.Detail                `json:"details,omitempty"`
+	Fields  map[string][]fmsg.FieldIssue `json:"fields,omitempty"`
+}
+
+// Meta is the context metadata of an error chain. Keys with a single value are
+// encoded as a string and keys with multiple values, see `fctx.Append`, are
+// encoded as a list of strings.
+type Meta map[string][]string
+
+func (m Meta) MarshalJSON() ([]byte, error) {
+	values := make(map[string]any, len(m))
+	for k, v := range m {
+		if len(v) == 1 {
+			values[k] = v[0]
+		} else {
+			values[k] = v
+		}
+	}
+
+	return json.Marshal(values)
+}
+
+func (m *Meta) UnmarshalJSON(data []byte) error {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	*m = make(Meta, len(values))
+	for k, raw := range values {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			(*m)[k] = []string{s}
+			continue
+		}
+
+		var list []string
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return err
+		}
+		(*m)[k] = list
+	}
+
+	return nil
+}
+
+// Option adds additional information from an error to a Document.
+type Option func(d *Document, err error)
+
+// WithTags includes every tag from the error chain, see `ftag.GetAll`.
+func WithTags() Option {
+	return func(d *Document, err error) {
+		d.Tags = ftag.GetAll(err)
+	}
+}
+
+// WithMeta includes the context metadata from the error chain, see
+// `fctx.UnwrapValues`. Sensitive metadata, see `fctx.WithSensitive`, is left out
+// since documents are usually sent to other services.
+func WithMeta() Option {
+	return func(d *Document, err error) {
+		d.Meta = fctx.UnwrapValuesRedacted(err, fctx.Omitted)
+	}
+}
+
+// WithIssues includes the end-user issue messages from the error chain, see
+// `fmsg.GetIssues`, along with any field issues, see `fmsg.GetFieldIssues`. If
+// any issue has a code or a link, the issues are also included with their codes
+// and links, see `fmsg.GetDetails`.
+func WithIssues() Option {
+	return func(d *Document, err error) {
+		if issues := fmsg.GetIssues(err); len(issues) > 0 {
+			d.Issues = issues
+		}
+		d.Fields = fmsg.GetFieldIssues(err)
+
+		details := fmsg.GetDetails(err)
+		for _, detail := range details {
+			if detail.Code != "" || detail.Link != "" {
+				d.Details = details
+				break
+			}
+		}
+	}
+}
+
+// WithAll includes tags, metadata and issues.
+func WithAll() Option {
+	return func(d *Document, err error) {
+		WithTags()(d, err)
+		WithMeta()(d, err)
+		WithIssues()(d, err)
+	}
+}
+
+// Encode builds a Document from an error. The message and chain are always
+// present and any other information is added by the options provided.
+func Encode(err error, opts ...Option) *Document {
+	if err == nil {
+		return nil
+	}
+
+	d := &Document{
+		Message: err.Error(),
+		Chain:   fault.Flatten(err),
+	}
+
+	for _, fn := range opts {
+		fn(d, err)
+	}
+
+	return d
+}
+
+// Marshal is a shorthand for calling `json.Marshal` on the result of `Encode`.
+func Marshal(err error, opts ...Option) ([]byte, error) {
+	return json.Marshal(Encode(err, opts...))
+}
+
+// Unmarshal is a shorthand for calling `json.Unmarshal` into a Document.
+func Unmarshal(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Decode reconstructs an error from a Document, usually one received from some
+// other service. The chain of the resulting error is the same as the original
+// except every step is marked as remote. Any tags, metadata and issues in the
+// document are restored so they can be accessed as usual with `ftag.Get`,
+// `fctx.Unwrap` and `fmsg.GetIssue`.
+func Decode(d *Document) error {
+	if d == nil {
+		return nil
+	}
+
+	chain := d.Chain
+	if len(chain) == 0 {
+		if d.Message == "" {
+			return nil
+		}
+		chain = fault.Chain{{Message: d.Message}}
+	}
+
+	w := []fault.Wrapper{}
+
+	if len(d.Meta) > 0 {
+		ctx := context.Background()
+		for _, k := range sortedKeys(d.Meta) {
+			values := d.Meta[k]
+			if len(values) == 1 {
+				ctx = fctx.WithMeta(ctx, k, values[0])
+				continue
+			}
+			for _, v := range values {
+				ctx = fctx.Append(ctx, k, v)
+			}
+		}
+
+		w = append(w, fctx.With(ctx))
+	}
+
+	if len(d.Fields) > 0 {
+		fields := []fmsg.FieldIssue{}
+		for _, k := range sortedKeys(d.Fields) {
+			fields = append(fields, d.Fields[k]...)
+		}
+
+		w = append(w, fmsg.WithFields("", fields...))
+	}
+
+	// tags and issues are listed outermost first so they are applied in reverse.
+	for i := len(d.Tags) - 1; i >= 0; i-- {
+		w = append(w, ftag.With(d.Tags[i]))
+	}
+	if len(d.Details) > 0 {
+		for i := len(d.Details) - 1; i >= 0; i-- {
+			w = append(w, fmsg.WithDetail("", d.Details[i]))
+		}
+	} else {
+		for i := len(d.Issues) - 1; i >= 0; i-- {
+			w = append(w, fmsg.WithDesc("", d.Issues[i]))
+		}
+	}
+
+	return fault.Remote(chain, w...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -2,6 +2,7 @@ package fault
 
 import (
 	"errors"
+	"strings"
 )
 
 // Chain represents an unwound error chain. Each step is a useful error. Errors
@@ -13,28 +14,195 @@ type Chain []Step
 // message is present if the underlying error provided a message. Note that not
 // all errors provide errors or locations. If both are missing, it's omitted.
 type Step struct {
-	Location string
-	Message  string
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message,omitempty"`
+
+	// Function, Package, File and Line are the individual parts of Location.
+	// The function name is fully qualified, for example "net/http.(*Client).Do"
+	// and the package is its import path, for example "net/http".
+	Function string `json:"function,omitempty"`
+	Package  string `json:"package,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+
+	// Remote is set for steps that were decoded from an error chain which was
+	// created somewhere else, such as another service. See Remote.
+	Remote bool `json:"remote,omitempty"`
+
+	// err is the error value that provided the message, nil for steps that only
+	// represent a location where an error was wrapped.
+	err error
+
+	// pc is the unresolved program counter for Location and stack holds the
+	// call stack, it's a pointer so that steps remain comparable.
+	pc    uintptr
+	stack *trace
+}
+
+// trace is the call stack of a step, the program counters are resolved into
+// frames when the chain is resolved.
+type trace struct {
+	pcs    []uintptr
+	frames []Frame
+}
+
+func newTrace(pcs []uintptr) *trace {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	return &trace{pcs: pcs}
+}
+
+func (t *trace) programCounters() []uintptr {
+	if t == nil {
+		return nil
+	}
+
+	return t.pcs
+}
+
+// Placeholder returns true if the step's message is only a placeholder, such as
+// "<fctx>", which wrappers without a message of their own use so the location
+// where they wrapped the error is kept in the chain. Placeholder messages are
+// left out of Error and usually should be left out of any other output too.
+func (s Step) Placeholder() bool {
+	return isInternalString(s.Message)
+}
+
+// Stack returns the full call stack from where the error was created or
+// wrapped. This is only present when stack capture is enabled, see the
+// SetStackDepth function for details. Frames which are already present in the
+// stack of the next step in the chain are omitted.
+func (s Step) Stack() []Frame {
+	if s.stack == nil {
+		return nil
+	}
+
+	return s.stack.frames
+}
+
+// Tree represents an unwound error tree. It's the same as a Chain except that
+// errors which wrap more than one error, such as those created by errors.Join,
+// branch out into one sub-tree per wrapped error instead of being treated as a
+// single step with a combined message.
+type Tree struct {
+	// Chain is the list of steps leading up to the branching point, in the same
+	// order as the output of Flatten.
+	Chain Chain `json:"chain"`
+
+	// Branches contains one tree for each of the errors wrapped by the error at
+	// the bottom of the chain. Empty if the chain does not branch.
+	Branches []Tree `json:"branches,omitempty"`
+}
+
+// multiError is satisfied by errors that wrap more than one error, such as the
+// errors returned from errors.Join and fmt.Errorf with multiple %w verbs.
+type multiError interface {
+	Unwrap() []error
 }
 
 // Flatten attempts to derive more useful structured information from an error
 // chain. If the input is a fault error, the output will contain an easy to use
 // error chain list with location information and individual error messages.
+//
+// Flatten only follows errors.Unwrap so errors which wrap multiple errors will
+// be represented as a single step. Use FlattenTree to inspect every branch.
+//
+// Messages are redacted using the redactors set with SetRedactors.
 func Flatten(err error) Chain {
 	if err == nil {
 		return nil
 	}
 
-	// first, flatten the call tree into an array so it's easier to work with.
+	return redactChain(resolve(flatten(unwind(err), false)))
+}
+
+// FlattenRaw works like Flatten except that messages are not redacted. Only use
+// this where the original messages are needed and will not be exposed, such as
+// a restricted error reporting service.
+//
+// Errors from other packages which wrap a Fault error, such as fmt.Errorf with
+// %w, include the redacted message of the Fault error in their own message so
+// it's replaced with the original. Only the messages of the steps are raw, the
+// Error method of each error in the chain, such as Chain.Root, still redacts.
+func FlattenRaw(err error) Chain {
+	if err == nil {
+		return nil
+	}
+
+	return resolve(flatten(unwind(err), true))
+}
+
+// FlattenTree works like Flatten except that it also descends into errors that
+// wrap multiple errors. Each wrapped error becomes a branch of the tree.
+func FlattenTree(err error) *Tree {
+	return flattenTree(err, false)
+}
+
+// FlattenTreeRaw works like FlattenTree except that messages are not redacted,
+// in the same way as FlattenRaw.
+func FlattenTreeRaw(err error) *Tree {
+	return flattenTree(err, true)
+}
+
+func flattenTree(err error, raw bool) *Tree {
+	if err == nil {
+		return nil
+	}
+
+	flat := unwind(err)
+
+	t := &Tree{Chain: resolve(flatten(flat, raw))}
+	if !raw {
+		t.Chain = redactChain(t.Chain)
+	}
+
+	m, ok := flat[len(flat)-1].(multiError)
+	if !ok {
+		return t
+	}
+
+	messages := []string{}
+	for _, e := range m.Unwrap() {
+		if b := flattenTree(e, raw); b != nil {
+			t.Branches = append(t.Branches, *b)
+			messages = append(messages, messageOf(e, raw))
+		}
+	}
+
+	// The branching error's message is usually just the messages of each of
+	// its branches joined together, which is already represented by branches.
+	if len(t.Chain) > 0 && t.Chain[0].Message == strings.Join(messages, "\n") {
+		if t.Chain[0].Location == "" {
+			t.Chain = t.Chain[1:]
+		} else {
+			t.Chain[0].Message = ""
+		}
+	}
+
+	return t
+}
+
+// unwind flattens the call tree into an array so it's easier to work with.
+func unwind(err error) []error {
 	flat := []error{}
 	for err != nil {
 		flat = append(flat, err)
 		err = errors.Unwrap(err)
 	}
 
-	lastLocation := ""
+	return flat
+}
+
+// flatten builds the chain from the unwound errors, if raw is set the messages
+// of errors from other packages are not redacted, see rawMessage.
+func flatten(flat []error, raw bool) Chain {
+	var lastPC uintptr
+	var lastStack []uintptr
 
 	var f Chain
+loop:
 	for i := 0; i < len(flat); i++ {
 		err := flat[i]
 
@@ -48,40 +216,181 @@ func Flatten(err error) Chain {
 		// exist to contain other errors that actually contain information,
 		// store the container's recorded location for usage with the next item.
 		case *container:
-			if _, ok := next.(*container); ok && unwrapped.location != "" {
+			if _, ok := next.(*container); ok && unwrapped.pc != 0 {
 				// Having 2 containers back to back can happen if we're using .Wrap without using any wrappers. In that
 				// case, we add a Step to avoid losing the location whe the wrapping occurred
 				f = append([]Step{{
-					Location: unwrapped.location,
-					Message:  "",
+					Message: "",
+					pc:      unwrapped.pc,
+					stack:   newTrace(unwrapped.stack),
 				}}, f...)
 			}
-			lastLocation = unwrapped.location
+			lastPC = unwrapped.pc
+			lastStack = unwrapped.stack
+
+		// Remote errors contain the rest of the chain, the errors beneath them
+		// only exist to restore information for other utilities.
+		case *remote:
+			f = append(unwrapped.steps(), f...)
+			break loop
 
 		case *fundamental:
 			f = append([]Step{{
-				Location: unwrapped.location,
-				Message:  err.Error(),
+				Message: unwrapped.msg,
+				err:     err,
+				pc:      unwrapped.pc,
+				stack:   newTrace(unwrapped.stack),
 			}}, f...)
 
-			lastLocation = ""
+			lastPC = 0
+			lastStack = nil
 
 		default:
-			message := err.Error()
+			message := messageOf(err, raw)
 
 			// de-duplicate identical error messages
 			if next != nil {
-				if message == next.Error() {
+				if message == messageOf(next, raw) {
 					continue
 				}
 			}
 
 			f = append([]Step{{
-				Location: lastLocation,
-				Message:  message,
+				Message: message,
+				err:     err,
+				pc:      lastPC,
+				stack:   newTrace(lastStack),
 			}}, f...)
 		}
 	}
 
 	return f
 }
+
+// resolve populates the locations of each step in the chain. This is deferred
+// until a chain is actually inspected as it's relatively expensive.
+func resolve(c Chain) Chain {
+	trimStacks(c)
+
+	for i := range c {
+		if f, ok := getFrame(c[i].pc); ok {
+			c[i].Location = f.String()
+			c[i].Function = f.Function
+			c[i].Package = f.Package
+			c[i].File = f.File
+			c[i].Line = f.Line
+		}
+		if c[i].stack != nil && c[i].stack.pcs != nil {
+			c[i].stack.frames = frames(c[i].stack.pcs)
+		}
+	}
+
+	return c
+}
+
+// redactChain applies the redactors to the message of each step.
+func redactChain(c Chain) Chain {
+	r := getRedactors()
+	if len(r) == 0 {
+		return c
+	}
+
+	for i := range c {
+		if !isInternalString(c[i].Message) {
+			c[i].Message = redact(r, c[i].Message)
+		}
+	}
+
+	return c
+}
+
+// messageOf returns the message of an error, without redaction if raw is set.
+func messageOf(err error, raw bool) string {
+	if raw {
+		return rawMessage(err)
+	}
+
+	return err.Error()
+}
+
+// rawMessage returns the message of an error without redaction. Errors from
+// other packages which wrap a Fault error include its redacted message in their
+// own, so it's replaced with the original message of the wrapped error.
+func rawMessage(err error) string {
+	switch e := err.(type) {
+	case *fundamental:
+		return e.msg
+	case *container, *remote:
+		return joinMessages(e, true)
+	}
+
+	message := err.Error()
+	if len(getRedactors()) == 0 {
+		return message
+	}
+
+	var wrapped []error
+	if m, ok := err.(multiError); ok {
+		wrapped = m.Unwrap()
+	} else if e := errors.Unwrap(err); e != nil {
+		wrapped = []error{e}
+	}
+
+	// the messages of multiple errors appear in order so each one is searched
+	// for after the last, a single error's message is usually at the end.
+	pos := 0
+	for _, e := range wrapped {
+		redacted := e.Error()
+		if redacted == "" {
+			continue
+		}
+
+		i := strings.LastIndex(message, redacted)
+		if len(wrapped) > 1 {
+			if i = strings.Index(message[pos:], redacted); i >= 0 {
+				i += pos
+			}
+		}
+		if i < 0 {
+			continue
+		}
+
+		raw := rawMessage(e)
+		message = message[:i] + raw + message[i+len(redacted):]
+		pos = i + len(raw)
+	}
+
+	return message
+}
+
+// trimStacks removes the frames from each step's stack that are also present at
+// the bottom of the next step's stack. This leaves only the frames that are
+// between where the error was created or wrapped and where it was next wrapped.
+func trimStacks(c Chain) {
+	for i := 0; i < len(c)-1; i++ {
+		inner := c[i].stack.programCounters()
+		if len(inner) == 0 {
+			continue
+		}
+
+		var outer []uintptr
+		for j := i + 1; j < len(c) && outer == nil; j++ {
+			outer = c[j].stack.programCounters()
+		}
+		if outer == nil {
+			return
+		}
+
+		n := 0
+		for n < len(inner) && n < len(outer) && inner[len(inner)-1-n] == outer[len(outer)-1-n] {
+			n++
+		}
+
+		// always keep at least the frame for the step's own location.
+		if n == len(inner) {
+			n--
+		}
+
+		c[i].stack.pcs = inner[:len(inner)-n]
+	}
+}
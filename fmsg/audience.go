@@ -0,0 +1,107 @@
+package fmsg
+
+import (
+	"errors"
+	"strings"
+)
+
+// Audience is who an issue message is intended for. Each audience is shown more
+// detail than the one before it.
+type Audience int
+
+const (
+	// EndUser is the customer or visitor using your product. Messages for end
+	// users are set with `WithDesc` and should never reveal internal details.
+	EndUser Audience = iota
+
+	// Operator is a member of staff, such as support, who needs more detail than
+	// an end user to help them but doesn't need to know how the code works.
+	// Messages for operators are set with `WithOperatorDesc`.
+	Operator
+
+	// Developer is someone working on the code, the internal message of each
+	// wrap is intended for developers.
+	Developer
+)
+
+func (a Audience) String() string {
+	switch a {
+	case EndUser:
+		return "end_user"
+	case Operator:
+		return "operator"
+	case Developer:
+		return "developer"
+	}
+	return "unknown"
+}
+
+// WithOperatorDesc is the same as `WithDesc` except an additional description
+// is set for operators, such as support staff, which can be more detailed than
+// the description for end users. Use `GetIssuesFor` to read it.
+//
+//	fmsg.WithOperatorDesc("charge failed",
+//		"Your payment could not be processed.",
+//		"The card was declined by the payment provider with reason: insufficient funds.",
+//	)
+func WithOperatorDesc(internal, description, operator string) func(error) error {
+	return func(err error) error {
+		if err == nil {
+			return nil
+		}
+
+		return &withMessage{
+			underlying: err,
+			internal:   internal,
+			external:   description,
+			operator:   operator,
+		}
+	}
+}
+
+// GetIssueFor returns a space-joined string of all the issue messages in the
+// error chain for an audience, see `GetIssuesFor`.
+func GetIssueFor(err error, a Audience) Issue {
+	return Issue(strings.Join(GetIssuesFor(err, a), " "))
+}
+
+// GetIssuesFor returns the issue messages in the error chain for an audience.
+// When a wrap doesn't have a message for the audience, the message for the
+// audience before it is used instead:
+//
+//   - EndUser: the description, the same as `GetIssues`.
+//   - Operator: the operator description, otherwise the description.
+//   - Developer: the internal message, otherwise the operator description or
+//     the description.
+//
+// Audiences never fall back to a message for an audience after them, so end
+// users and operators will never see internal messages.
+func GetIssuesFor(err error, a Audience) []Issue {
+	p := []Issue{}
+	c := getCatalog()
+	meta := templateArgs(err)
+
+	for err != nil {
+		if wm, ok := err.(*withMessage); ok {
+			if issue := wm.issueFor(c, a, meta); issue != "" {
+				p = append(p, issue)
+			}
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return p
+}
+
+func (e *withMessage) issueFor(c *Catalog, a Audience, meta Args) Issue {
+	if a >= Developer && e.internal != "" {
+		return e.internal
+	}
+
+	if a >= Operator && e.operator != "" {
+		return render(e.operator, meta)
+	}
+
+	return e.issue(c, "", meta)
+}
@@ -0,0 +1,422 @@
+package fmsg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Args are the arguments of a message template, see `WithKey`.
+type Args map[string]any
+
+// PluralRule returns the plural category, such as "one" or "other", of a count
+// for a language. Categories are the same as those used by Unicode CLDR: "zero",
+// "one", "two", "few", "many" and "other".
+type PluralRule func(n int) string
+
+// defaultPluralRule is correct for English and many other languages, use
+// `Catalog.SetPluralRule` for languages with different rules.
+func defaultPluralRule(n int) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+var pluralCategories = map[string]bool{
+	"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true,
+}
+
+type message struct {
+	text  string
+	forms map[string]string // plural forms, keyed by category
+}
+
+// Catalog stores translations of end-user messages for each locale. Messages
+// are templates where `{name}` is replaced with the argument called name. A
+// message can also have plural forms which are chosen using the argument called
+// "count".
+type Catalog struct {
+	fallback string
+
+	mu       sync.RWMutex
+	messages map[string]map[string]message
+	plurals  map[string]PluralRule
+}
+
+// NewCatalog creates an empty catalog. The fallback locale is used when a
+// message doesn't exist for a requested locale, or for the language of the
+// locale, such as "pt" for "pt-BR".
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: normaliseLocale(fallback),
+		messages: map[string]map[string]message{},
+		plurals:  map[string]PluralRule{},
+	}
+}
+
+// Add adds a message to the catalog for a locale.
+func (c *Catalog) Add(locale, key, text string) {
+	c.add(locale, key, message{text: text})
+}
+
+// AddPlural adds a message with plural forms to the catalog for a locale. The
+// forms are keyed by their plural category and "other" should always be present.
+//
+//	c.AddPlural("en", "cart.items", map[string]string{
+//		"one":   "Your cart has {count} item.",
+//		"other": "Your cart has {count} items.",
+//	})
+func (c *Catalog) AddPlural(locale, key string, forms map[string]string) {
+	c.add(locale, key, message{forms: forms})
+}
+
+func (c *Catalog) add(locale, key string, m message) {
+	locale = normaliseLocale(locale)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages[locale] == nil {
+		c.messages[locale] = map[string]message{}
+	}
+	c.messages[locale][key] = m
+}
+
+// SetPluralRule sets the rule used to choose plural forms for a locale. By
+// default, a count of 1 is "one" and every other count is "other".
+func (c *Catalog) SetPluralRule(locale string, rule PluralRule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.plurals[normaliseLocale(locale)] = rule
+}
+
+// LoadJSON adds the messages from a JSON object to the catalog for a locale.
+// Values are either a message, an object of plural forms or an object of more
+// messages, in which case their keys are joined with a dot:
+//
+//	{
+//		"user": {
+//			"not_found": "Cannot find the user {name}."
+//		},
+//		"cart.items": {
+//			"one": "Your cart has {count} item.",
+//			"other": "Your cart has {count} items."
+//		}
+//	}
+func (c *Catalog) LoadJSON(locale string, data []byte) error {
+	return c.Load(locale, data, json.Unmarshal)
+}
+
+// Load adds the messages from a document in any format to the catalog for a
+// locale. The document is decoded by unmarshal, such as `yaml.Unmarshal`, and it
+// must have the same structure as the object used by `LoadJSON`.
+//
+//	err := catalog.Load("pt-BR", data, yaml.Unmarshal)
+func (c *Catalog) Load(locale string, data []byte, unmarshal func([]byte, any) error) error {
+	var v map[string]any
+	if err := unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	return c.load(locale, "", v)
+}
+
+// Format is a file format which can be loaded by `LoadFS` in addition to JSON.
+type Format struct {
+	// Ext is the file extension of the format, including the dot, such as ".yaml".
+	Ext string
+
+	// Unmarshal decodes a file, such as `yaml.Unmarshal`, see `Load`.
+	Unmarshal func([]byte, any) error
+}
+
+// LoadFS adds the messages from every file in a directory of a file system that
+// is named after a locale and has a ".json" extension, or the extension of one of
+// the formats, such as "en.json" or "pt-BR.yaml". This works well with `embed.FS`.
+//
+//	//go:embed locales
+//	var locales embed.FS
+//
+//	err := catalog.LoadFS(locales, "locales", fmsg.Format{Ext: ".yaml", Unmarshal: yaml.Unmarshal})
+func (c *Catalog) LoadFS(fsys fs.FS, dir string, formats ...Format) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	unmarshalers := map[string]func([]byte, any) error{".json": json.Unmarshal}
+	for _, f := range formats {
+		unmarshalers[f.Ext] = f.Unmarshal
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		ext := path.Ext(e.Name())
+		locale := strings.TrimSuffix(e.Name(), ext)
+
+		unmarshal, ok := unmarshalers[ext]
+		if !ok {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+
+		if err := c.Load(locale, data, unmarshal); err != nil {
+			return fmt.Errorf("%s: %w", e.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Catalog) load(locale, prefix string, v map[string]any) error {
+	for k, value := range v {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch value := value.(type) {
+		case string:
+			c.Add(locale, key, value)
+
+		case map[string]any:
+			if forms, ok := pluralForms(value); ok {
+				c.AddPlural(locale, key, forms)
+			} else if err := c.load(locale, key, value); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("message %q must be a string or an object, not %T", key, value)
+		}
+	}
+
+	return nil
+}
+
+// pluralForms returns the forms of an object if every key is a plural category.
+func pluralForms(v map[string]any) (map[string]string, bool) {
+	forms := make(map[string]string, len(v))
+	for k, value := range v {
+		s, ok := value.(string)
+		if !ok || !pluralCategories[k] {
+			return nil, false
+		}
+		forms[k] = s
+	}
+
+	return forms, len(forms) > 0
+}
+
+// Render returns the message for a key in the locale closest to the one that was
+// requested with the arguments applied to it. If the message doesn't exist in
+// the requested locale, its language or the fallback locale, false is returned.
+func (c *Catalog) Render(locale, key string, args Args) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, l := range c.locales(locale) {
+		m, ok := c.messages[l][key]
+		if !ok {
+			continue
+		}
+
+		text := m.text
+		if m.forms != nil {
+			text = m.forms[c.pluralCategory(l, m, args)]
+			if text == "" {
+				text = m.forms["other"]
+			}
+		}
+
+		return render(text, args), true
+	}
+
+	return "", false
+}
+
+func (c *Catalog) pluralCategory(locale string, m message, args Args) string {
+	n, ok := count(args["count"])
+	if !ok {
+		return "other"
+	}
+
+	// an explicit zero form is used for zero, even if the language doesn't
+	// have a zero category, such as "Your cart is empty."
+	if _, ok := m.forms["zero"]; ok && n == 0 {
+		return "zero"
+	}
+
+	// rules are only looked up for the language of the message, not the
+	// fallback, since they're different for each language.
+	locales := c.locales(locale)
+
+	rule := defaultPluralRule
+	for _, l := range locales[:len(locales)-1] {
+		if r, ok := c.plurals[l]; ok {
+			rule = r
+			break
+		}
+	}
+
+	return rule(n)
+}
+
+// locales returns the locales to look for messages in, in order. For example,
+// "pt-BR" with a fallback of "en" is "pt-BR", "pt" and then "en".
+func (c *Catalog) locales(locale string) []string {
+	locales := []string{}
+
+	for l := normaliseLocale(locale); l != ""; {
+		locales = append(locales, l)
+
+		i := strings.LastIndex(l, "-")
+		if i < 0 {
+			break
+		}
+		l = l[:i]
+	}
+
+	return append(locales, c.fallback)
+}
+
+// GetIssue is the same as `fmsg.GetIssue` except messages added with `WithKey`
+// are rendered in the requested locale using this catalog.
+func (c *Catalog) GetIssue(err error, locale string) Issue {
+	return Issue(strings.Join(c.GetIssues(err, locale), " "))
+}
+
+// GetIssues is the same as `fmsg.GetIssues` except messages added with `WithKey`
+// are rendered in the requested locale using this catalog.
+func (c *Catalog) GetIssues(err error, locale string) []Issue {
+	p := []Issue{}
+	meta := templateArgs(err)
+
+	for err != nil {
+		if wm, ok := err.(*withMessage); ok {
+			if issue := wm.issue(c, locale, meta); issue != "" {
+				p = append(p, issue)
+			}
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return p
+}
+
+func normaliseLocale(locale string) string {
+	return strings.ReplaceAll(locale, "_", "-")
+}
+
+func count(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case uint:
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	case float32:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+
+	return 0, false
+}
+
+// render replaces each `{name}` in a template with the argument called name.
+// Placeholders without an argument are left as they are.
+func render(template string, args Args) string {
+	if len(args) == 0 || !strings.Contains(template, "{") {
+		return template
+	}
+
+	b := strings.Builder{}
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(template[:start])
+		if v, ok := args[template[start+1:end]]; ok {
+			fmt.Fprint(&b, v)
+		} else {
+			b.WriteString(template[start : end+1])
+		}
+
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+
+	return b.String()
+}
+
+var catalog atomic.Value // *Catalog
+
+// SetCatalog sets the catalog used by `GetIssueLocalized` and by `GetIssue` to
+// render messages added with `WithKey`.
+func SetCatalog(c *Catalog) {
+	catalog.Store(c)
+}
+
+func getCatalog() *Catalog {
+	c, _ := catalog.Load().(*Catalog)
+	return c
+}
+
+// GetIssueLocalized is the same as `GetIssue` except messages added with
+// `WithKey` are rendered in the requested locale using the catalog set with
+// `SetCatalog`. Messages without a key are returned as they are.
+//
+//	issue := fmsg.GetIssueLocalized(err, user.Locale)
+func GetIssueLocalized(err error, locale string) Issue {
+	return Issue(strings.Join(GetIssuesLocalized(err, locale), " "))
+}
+
+// GetIssuesLocalized is the same as `GetIssueLocalized` except the messages are
+// returned individually, in the same way as `GetIssues`.
+func GetIssuesLocalized(err error, locale string) []Issue {
+	c := getCatalog()
+	if c == nil {
+		c = NewCatalog("")
+	}
+
+	return c.GetIssues(err, locale)
+}
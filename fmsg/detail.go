@@ -0,0 +1,76 @@
+package fmsg
+
+import "errors"
+
+// Detail is an end-user issue message along with a stable code which identifies
+// the issue and an optional link to documentation about it. Codes are easier to
+// search for than messages, which may change or be translated.
+type Detail struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+	Link    string `json:"link,omitempty"`
+}
+
+// WithDetail is the same as `WithDesc` except the description is a Detail, so
+// the issue also has a code and a link.
+//
+//	fmsg.WithDetail("user not found", fmsg.Detail{
+//		Code:    "USR-404",
+//		Message: "Cannot find the requested user.",
+//		Link:    "https://example.com/docs/errors/USR-404",
+//	})
+//
+// The message is returned from `GetIssue` like any other description.
+func WithDetail(internal string, d Detail) func(error) error {
+	return func(err error) error {
+		if err == nil {
+			return nil
+		}
+
+		return &withMessage{
+			underlying: err,
+			internal:   internal,
+			external:   d.Message,
+			code:       d.Code,
+			link:       d.Link,
+		}
+	}
+}
+
+// GetDetails returns the end-user issues in the error chain with their codes and
+// links, in the same order as `GetIssues`. Issues which were added without a
+// code have only a message.
+func GetDetails(err error) []Detail {
+	p := []Detail{}
+	c := getCatalog()
+	meta := templateArgs(err)
+
+	for err != nil {
+		if wm, ok := err.(*withMessage); ok {
+			d := Detail{
+				Code:    wm.code,
+				Message: wm.issue(c, "", meta),
+				Link:    wm.link,
+			}
+			if d != (Detail{}) {
+				p = append(p, d)
+			}
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return p
+}
+
+// GetCode returns the code of the outermost issue in the error chain which has
+// one, in the same way that `ftag.Get` returns the outermost tag.
+func GetCode(err error) string {
+	for _, d := range GetDetails(err) {
+		if d.Code != "" {
+			return d.Code
+		}
+	}
+
+	return ""
+}
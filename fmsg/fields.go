@@ -0,0 +1,60 @@
+package fmsg
+
+import "errors"
+
+// FieldIssue describes a problem with a single field of some input, such as a
+// form or an API request, so the problem can be shown next to the field.
+type FieldIssue struct {
+	// Field is the path to the field, such as "address.postcode" or
+	// "items[3].qty".
+	Field string `json:"field"`
+
+	// Message is intended for the end-user to read, in the same way as an issue.
+	Message string `json:"message"`
+
+	// Code optionally identifies the kind of problem, such as "required", so
+	// clients can decide how to present it.
+	Code string `json:"code,omitempty"`
+}
+
+// WithFields wraps an error with an internal message and any number of field
+// issues, which can be retrieved with `GetFieldIssues`.
+//
+//	return fault.Wrap(err, fmsg.WithFields("invalid address",
+//		fmsg.FieldIssue{Field: "address.postcode", Message: "Enter a valid postcode.", Code: "format"},
+//		fmsg.FieldIssue{Field: "address.city", Message: "Enter a city.", Code: "required"},
+//	))
+func WithFields(internal string, issues ...FieldIssue) func(error) error {
+	return func(err error) error {
+		if err == nil {
+			return nil
+		}
+
+		return &withMessage{
+			underlying: err,
+			internal:   internal,
+			fields:     issues,
+		}
+	}
+}
+
+// GetFieldIssues returns every field issue in the error chain grouped by their
+// field. Issues for the same field are in the same order as `GetIssues`.
+func GetFieldIssues(err error) map[string][]FieldIssue {
+	var fields map[string][]FieldIssue
+
+	for err != nil {
+		if wm, ok := err.(*withMessage); ok {
+			for _, f := range wm.fields {
+				if fields == nil {
+					fields = map[string][]FieldIssue{}
+				}
+				fields[f.Field] = append(fields[f.Field], f)
+			}
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return fields
+}
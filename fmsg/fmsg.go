@@ -16,6 +16,12 @@ type withMessage struct {
 	underlying error
 	internal   string
 	external   string
+	operator   string // see WithOperatorDesc
+	code       string // see WithDetail
+	link       string
+	key        string // see WithKey
+	args       Args
+	fields     []FieldIssue
 }
 
 // Wrap wraps an error with an internal and an external message. The internal
@@ -28,7 +34,9 @@ func Wrap(err error, internal, external string) error {
 	}
 
 	return &withMessage{
-		err, internal, external,
+		underlying: err,
+		internal:   internal,
+		external:   external,
 	}
 }
 
@@ -56,6 +64,29 @@ func WithDesc(internal, description string) func(error) error {
 	}
 }
 
+// WithKey is the same as `WithDesc` except the description is a key of a message
+// in a `Catalog` so it can be translated into the end-user's language, see
+// `GetIssueLocalized`. The arguments are used to fill in the message template.
+//
+//	fmsg.WithKey("user not found", "user.not_found", fmsg.Args{"name": name})
+//
+// `GetIssue` renders the message in the catalog's fallback locale. If there is
+// no catalog or the message doesn't exist, the key is used as the message.
+func WithKey(internal, key string, args Args) func(error) error {
+	return func(err error) error {
+		if err == nil {
+			return nil
+		}
+
+		return &withMessage{
+			underlying: err,
+			internal:   internal,
+			key:        key,
+			args:       args,
+		}
+	}
+}
+
 // Error satisfies the error interface by returning the internal error message.
 func (e *withMessage) Error() string { return e.internal }
 
@@ -71,11 +102,13 @@ func GetIssue(err error) Issue {
 // GetIssues returns all end-user intended messages in the input error chain.
 func GetIssues(err error) []Issue {
 	p := []Issue{}
+	c := getCatalog()
+	meta := templateArgs(err)
 
 	for err != nil {
 		if wm, ok := err.(*withMessage); ok {
-			if wm.external != "" {
-				p = append(p, wm.external)
+			if issue := wm.issue(c, "", meta); issue != "" {
+				p = append(p, issue)
 			}
 		}
 
@@ -84,3 +117,21 @@ func GetIssues(err error) []Issue {
 
 	return p
 }
+
+// issue returns the end-user message, which is rendered from the catalog if the
+// message was added with a key. Metadata from the error chain fills in any
+// placeholders, see `SetTemplateKeys`, unless there's an argument with the same
+// name.
+func (e *withMessage) issue(c *Catalog, locale string, meta Args) Issue {
+	if e.key == "" {
+		return render(e.external, meta)
+	}
+
+	if c != nil {
+		if s, ok := c.Render(locale, e.key, merge(meta, e.args)); ok {
+			return s
+		}
+	}
+
+	return e.key
+}
@@ -0,0 +1,72 @@
+package fmsg
+
+import (
+	"sync/atomic"
+
+	"github.com/Southclaws/fault/fctx"
+)
+
+var templateKeys atomic.Value // map[string]bool
+
+// SetTemplateKeys sets which keys of the context metadata in an error chain, see
+// `fctx.Unwrap`, may be used in descriptions. A description can then refer to
+// the metadata with a `{key}` placeholder which is filled in when the issue is
+// read, even though the metadata was added further down the call chain:
+//
+//	fmsg.SetTemplateKeys("order_id")
+//
+//	fmsg.WithDesc("ship order", "Order {order_id} could not be shipped.")
+//
+// Since descriptions are shown to end-users, only keys which are safe for them
+// to see should be allowed. By default, no keys are allowed and placeholders are
+// left as they are. Sensitive metadata, see `fctx.WithSensitive`, is never used
+// even if its key is allowed, no matter which redaction is set with
+// `fctx.SetRedaction`. Calling it again replaces the keys.
+func SetTemplateKeys(keys ...string) {
+	m := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		m[k] = true
+	}
+
+	templateKeys.Store(m)
+}
+
+// templateArgs returns the allowed metadata of an error chain, sensitive
+// metadata is left out.
+func templateArgs(err error) Args {
+	allowed, _ := templateKeys.Load().(map[string]bool)
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	var args Args
+	for k, v := range fctx.UnwrapRedacted(err, fctx.Omitted) {
+		if !allowed[k] {
+			continue
+		}
+
+		if args == nil {
+			args = Args{}
+		}
+		args[k] = v
+	}
+
+	return args
+}
+
+// merge returns the metadata and arguments together, arguments take precedence.
+func merge(meta, args Args) Args {
+	if len(meta) == 0 {
+		return args
+	}
+
+	merged := make(Args, len(meta)+len(args))
+	for k, v := range meta {
+		merged[k] = v
+	}
+	for k, v := range args {
+		merged[k] = v
+	}
+
+	return merged
+}
@@ -0,0 +1,109 @@
+// Package fslog provides integration between Fault and the structured logging
+// package log/slog. Errors are logged as a group which contains the message and
+// the error chain along with the information from the other Fault utilities.
+//
+// Use `Err` to create an attribute for an error directly:
+//
+//	logger.Error("request failed", fslog.Err(err))
+//
+// Or configure a handler with `ReplaceAttr` so any error value is expanded:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+//		ReplaceAttr: fslog.ReplaceAttr,
+//	}))
+//
+//	logger.Error("request failed", slog.Any("error", err))
+package fslog
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fctx"
+	"github.com/Southclaws/fault/fmsg"
+	"github.com/Southclaws/fault/ftag"
+)
+
+// Key is the default key used for errors by `Err`.
+const Key = "error"
+
+// Err returns an attribute with the key "error" and the value from `Value`.
+func Err(err error) slog.Attr {
+	return slog.Attr{Key: Key, Value: Value(err)}
+}
+
+// Value returns a group value for an error containing the following items:
+//
+//   - message: the full error message.
+//   - chain: a group of each step in the error chain, keyed by their index.
+//     Placeholder messages, see `fault.Step.Placeholder`, are left out but
+//     their locations are kept.
+//   - kind: the error kind, if the error chain contains one, see `ftag.Get`.
+//   - meta: a group of context metadata, if any, see `fctx.Unwrap`.
+//   - restored: true, only if the metadata came from a restored context, see
+//     `fctx.IsRestored`.
+//   - issue: the end-user issue message, if any, see `fmsg.GetIssue`.
+func Value(err error) slog.Value {
+	if err == nil {
+		return slog.Value{}
+	}
+
+	attrs := []slog.Attr{
+		slog.String("message", err.Error()),
+	}
+
+	if chain := fault.Flatten(err); len(chain) > 0 {
+		steps := make([]slog.Attr, 0, len(chain))
+		for _, s := range chain {
+			step := []slog.Attr{}
+			if s.Message != "" && !s.Placeholder() {
+				step = append(step, slog.String("message", s.Message))
+			}
+			if s.Location != "" {
+				step = append(step, slog.String("location", s.Location))
+			}
+			if len(step) == 0 {
+				continue
+			}
+			steps = append(steps, slog.Attr{
+				Key:   strconv.Itoa(len(steps)),
+				Value: slog.GroupValue(step...),
+			})
+		}
+		attrs = append(attrs, slog.Attr{Key: "chain", Value: slog.GroupValue(steps...)})
+	}
+
+	if len(ftag.GetAll(err)) > 0 {
+		attrs = append(attrs, slog.String("kind", string(ftag.Get(err))))
+	}
+
+	if meta := fctx.UnwrapAttrs(err); len(meta) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "meta", Value: slog.GroupValue(meta...)})
+	}
+
+	if fctx.IsRestored(err) {
+		attrs = append(attrs, slog.Bool("restored", true))
+	}
+
+	if issue := fmsg.GetIssue(err); issue != "" {
+		attrs = append(attrs, slog.String("issue", issue))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// ReplaceAttr may be used as the `ReplaceAttr` function of slog's built-in
+// handlers. It replaces any attribute with an error value with the group from
+// `Value`. All other attributes are left untouched.
+func ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	if err, ok := a.Value.Any().(error); ok {
+		a.Value = Value(err)
+	}
+
+	return a
+}
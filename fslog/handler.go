@@ -0,0 +1,109 @@
+package fslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Southclaws/fault/fctx"
+)
+
+// HandlerOptions are options for a Handler. A zero HandlerOptions consists
+// entirely of default values.
+type HandlerOptions struct {
+	// Group, if set, nests all of the context metadata under a group with this
+	// name. Otherwise, metadata is added to the record alongside its attributes.
+	Group string
+}
+
+// Handler wraps another slog.Handler and adds the context metadata stored with
+// `fctx.WithMeta` or `fctx.WithAttrs` to every record that's logged with a context, such as with
+// `Logger.InfoContext` and `Logger.ErrorContext`.
+//
+// Metadata is added in order of its keys. When metadata isn't nested under a
+// group, attributes that are passed to the log call or added with `With` take
+// precedence over metadata with the same key, so they are never duplicated.
+type Handler struct {
+	next slog.Handler
+	opts HandlerOptions
+	keys map[string]struct{} // keys of attributes added with WithAttrs
+}
+
+// NewHandler creates a Handler which passes records on to next after adding
+// context metadata. If opts is nil, the default options are used.
+func NewHandler(next slog.Handler, opts *HandlerOptions) *Handler {
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	return &Handler{
+		next: next,
+		opts: *opts,
+	}
+}
+
+// Enabled reports whether the wrapped handler handles records at the level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the context metadata to the record and passes it on.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := fctx.GetAttrs(ctx)
+	if len(attrs) == 0 {
+		return h.next.Handle(ctx, r)
+	}
+
+	if h.opts.Group != "" {
+		r = r.Clone()
+		r.AddAttrs(slog.Attr{Key: h.opts.Group, Value: slog.GroupValue(attrs...)})
+		return h.next.Handle(ctx, r)
+	}
+
+	existing := make(map[string]struct{}, r.NumAttrs()+len(h.keys))
+	for k := range h.keys {
+		existing[k] = struct{}{}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		existing[a.Key] = struct{}{}
+		return true
+	})
+
+	r = r.Clone()
+	for _, a := range attrs {
+		if _, ok := existing[a.Key]; !ok {
+			r.AddAttrs(a)
+		}
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a new Handler whose wrapped handler has the attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.keys = make(map[string]struct{}, len(h.keys)+len(attrs))
+	for k := range h.keys {
+		h2.keys[k] = struct{}{}
+	}
+	for _, a := range attrs {
+		h2.keys[a.Key] = struct{}{}
+	}
+
+	return &h2
+}
+
+// WithGroup returns a new Handler whose wrapped handler has the group. Context
+// metadata is added to records so it will also be nested within the group and
+// only conflicts with attributes that are added after the group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.keys = nil
+
+	return &h2
+}
@@ -5,8 +5,9 @@ import "fmt"
 // New creates a new basic fault error.
 func New(message string, w ...Wrapper) error {
 	f := &fundamental{
-		msg:      message,
-		location: getLocation(),
+		msg:   message,
+		pc:    getPC(),
+		stack: getStack(),
 	}
 
 	var err error = f
@@ -20,17 +21,19 @@ func New(message string, w ...Wrapper) error {
 // Newf includes formatting specifiers.
 func Newf(message string, va ...any) error {
 	f := &fundamental{
-		msg:      fmt.Sprintf(message, va...),
-		location: getLocation(),
+		msg:   fmt.Sprintf(message, va...),
+		pc:    getPC(),
+		stack: getStack(),
 	}
 	return f
 }
 
 type fundamental struct {
-	msg      string
-	location string
+	msg   string
+	pc    uintptr
+	stack []uintptr
 }
 
 func (f *fundamental) Error() string {
-	return f.msg
+	return redact(getRedactors(), f.msg)
 }
@@ -1,6 +1,6 @@
 module github.com/Southclaws/fault
 
-go 1.18
+go 1.21
 
 require github.com/kr/pretty v0.3.1
 
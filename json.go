@@ -0,0 +1,62 @@
+package fault
+
+import "encoding/json"
+
+// errorJSON is the JSON representation of an error created or wrapped by Fault.
+type errorJSON struct {
+	Message string `json:"message"`
+	Chain   Chain  `json:"chain"`
+}
+
+// MarshalJSON encodes the error as an object containing the full error message
+// and the flattened error chain. See the fjson package for a richer document.
+func (f *container) MarshalJSON() ([]byte, error) {
+	return marshalError(f)
+}
+
+// MarshalJSON encodes the error in the same format as a wrapped error.
+func (f *fundamental) MarshalJSON() ([]byte, error) {
+	return marshalError(f)
+}
+
+// MarshalJSON encodes the error in the same format as a wrapped error.
+func (f *remote) MarshalJSON() ([]byte, error) {
+	return marshalError(f)
+}
+
+func marshalError(err error) ([]byte, error) {
+	return json.Marshal(errorJSON{
+		Message: err.Error(),
+		Chain:   Flatten(err),
+	})
+}
+
+// step has the same fields as Step without its methods, so it can be encoded
+// without calling MarshalJSON again.
+type step Step
+
+// MarshalJSON encodes the step along with its stack, if it has one.
+func (s Step) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		step
+		Stack []Frame `json:"stack,omitempty"`
+	}{step(s), s.Stack()})
+}
+
+// UnmarshalJSON decodes a step which was encoded with MarshalJSON.
+func (s *Step) UnmarshalJSON(data []byte) error {
+	v := struct {
+		*step
+		Stack []Frame `json:"stack"`
+	}{step: (*step)(s)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if len(v.Stack) > 0 {
+		s.stack = &trace{frames: v.Stack}
+	}
+
+	return nil
+}
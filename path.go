@@ -0,0 +1,89 @@
+package fault
+
+import (
+	"path"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// PathMode controls how source file paths are presented in locations.
+type PathMode int32
+
+const (
+	// PathAbsolute uses the full path of the source file on the machine that
+	// built the program, this is the default.
+	PathAbsolute PathMode = iota
+
+	// PathModule uses paths relative to the root of the main module, such as
+	// "internal/db/user.go". Files outside of the main module are prefixed with
+	// their package import path, such as "net/http/client.go". Files belonging
+	// to package main can't be resolved so they only show the file name.
+	PathModule
+
+	// PathBase uses only the name of the source file, such as "user.go".
+	PathBase
+)
+
+var pathMode int32
+
+// SetPathMode changes how source file paths are presented in the locations
+// returned from Flatten and printed with %+v. Absolute paths reveal the layout
+// of the machine that built the program and differ between environments, use a
+// different mode if you need locations to be stable across machines.
+func SetPathMode(m PathMode) {
+	atomic.StoreInt32(&pathMode, int32(m))
+}
+
+var (
+	mainModuleOnce sync.Once
+	mainModulePath string
+)
+
+// mainModule returns the path of the main module from the build information.
+func mainModule() string {
+	mainModuleOnce.Do(func() {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			mainModulePath = bi.Main.Path
+		}
+	})
+
+	return mainModulePath
+}
+
+// formatPath applies the current path mode to a source file path given the
+// package that the file belongs to.
+func formatPath(file, pkg string) string {
+	if file == "" {
+		return ""
+	}
+
+	switch PathMode(atomic.LoadInt32(&pathMode)) {
+	case PathModule:
+		name := path.Base(file)
+		if pkg == "" || pkg == "main" {
+			return name
+		}
+
+		// external test packages live in the same directory as the package.
+		pkg = strings.TrimSuffix(pkg, "_test")
+
+		if mod := mainModule(); mod != "" {
+			if pkg == mod {
+				return name
+			}
+			if strings.HasPrefix(pkg, mod+"/") {
+				return strings.TrimPrefix(pkg, mod+"/") + "/" + name
+			}
+		}
+
+		return pkg + "/" + name
+
+	case PathBase:
+		return path.Base(file)
+
+	default:
+		return file
+	}
+}
@@ -0,0 +1,71 @@
+package fault
+
+import "fmt"
+
+// Remote reconstructs an error from a chain that was flattened somewhere else,
+// such as in another service, and then serialised. Flatten returns the same
+// steps as the original chain with each one marked as remote. The result can be
+// wrapped just like any other error and those steps will appear after it.
+//
+// The wrappers are applied beneath the remote chain which allows them to restore
+// information for other utilities, such as tags or metadata, without adding any
+// steps to the chain. Returns nil if the chain is empty.
+func Remote(c Chain, w ...Wrapper) error {
+	if len(c) == 0 {
+		return nil
+	}
+
+	root := &remoteRoot{message: c[0].Message}
+
+	var err error = root
+	for _, fn := range w {
+		err = fn(err)
+	}
+
+	return &remote{
+		cause: err,
+		chain: c,
+		root:  root,
+	}
+}
+
+// remote holds the entire chain that was decoded, the cause is only used by the
+// utilities which look for their own error types using errors.Unwrap.
+type remote struct {
+	cause error
+	chain Chain
+	root  error
+}
+
+func (f *remote) Error() string { return errorMessage(f) }
+
+func (f *remote) Unwrap() error { return f.cause }
+
+func (f *remote) Format(s fmt.State, verb rune) { format(f, s, verb) }
+
+func (f *remote) steps() Chain {
+	c := make(Chain, len(f.chain))
+	for i, s := range f.chain {
+		c[i] = Step{
+			Location: s.Location,
+			Message:  s.Message,
+			Function: s.Function,
+			Package:  s.Package,
+			File:     s.File,
+			Line:     s.Line,
+			stack:    s.stack,
+			Remote:   true,
+		}
+	}
+
+	c[0].err = f.root
+
+	return c
+}
+
+// remoteRoot is the root cause of a remote error chain.
+type remoteRoot struct {
+	message string
+}
+
+func (f *remoteRoot) Error() string { return f.message }
@@ -0,0 +1,100 @@
+package fault
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// stackDepth is the maximum number of frames captured by New and Wrap. Values
+// of 1 or less disable stack capture and only the caller's location is stored.
+var stackDepth int32
+
+// SetStackDepth enables capturing the full call stack, up to a depth of n, each
+// time an error is created with New or wrapped with Wrap. This is useful when an
+// error passes through code that does not wrap it, which would otherwise leave a
+// gap in the locations recorded in the chain. Stacks are disabled by default and
+// can be disabled again by passing 0. Stacks are only captured for errors that
+// are created or wrapped after calling this function.
+//
+// When enabled, each Step returned from Flatten has a Stack and formatting
+// an error with %+v will print every frame instead of a single location.
+func SetStackDepth(n int) {
+	atomic.StoreInt32(&stackDepth, int32(n))
+}
+
+// Frame represents a single function call from a captured call stack.
+type Frame struct {
+	Function string `json:"function,omitempty"` // fully qualified function name, such as "net/http.(*Client).Do"
+	Package  string `json:"package,omitempty"`  // package import path, such as "net/http"
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// String returns the frame's location in the same format as Step.Location.
+func (f Frame) String() string {
+	return fmt.Sprintf("%s:%d", f.File, f.Line)
+}
+
+func getStack() []uintptr {
+	depth := atomic.LoadInt32(&stackDepth)
+	if depth <= 1 {
+		return nil
+	}
+
+	pc := make([]uintptr, depth)
+	n := runtime.Callers(3, pc)
+
+	return pc[:n]
+}
+
+func frames(pc []uintptr) []Frame {
+	if len(pc) == 0 {
+		return nil
+	}
+
+	fs := make([]Frame, 0, len(pc))
+	cf := runtime.CallersFrames(pc)
+	for {
+		f, more := cf.Next()
+		fs = append(fs, newFrame(f))
+		if !more {
+			break
+		}
+	}
+
+	return fs
+}
+
+func newFrame(f runtime.Frame) Frame {
+	fn, pkg := splitFunction(f.Function)
+
+	return Frame{
+		Function: fn,
+		Package:  pkg,
+		File:     formatPath(f.File, pkg),
+		Line:     f.Line,
+	}
+}
+
+// splitFunction returns a fully qualified function name and the import path
+// portion of it. The package name ends at the first dot after the last slash,
+// the runtime escapes any dots in the last element of the import path as "%2e"
+// so they are unescaped once the package has been found.
+func splitFunction(fn string) (string, string) {
+	slash := strings.LastIndex(fn, "/")
+	dot := strings.Index(fn[slash+1:], ".")
+	if dot < 0 {
+		return fn, ""
+	}
+
+	pkg := fn[:slash+1+dot]
+	if !strings.Contains(pkg, "%2e") {
+		return fn, pkg
+	}
+
+	unescaped := strings.ReplaceAll(pkg, "%2e", ".")
+
+	return unescaped + fn[len(pkg):], unescaped
+}
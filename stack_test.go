@@ -0,0 +1,23 @@
+package fault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFunction(t *testing.T) {
+	for _, tc := range []struct {
+		in, fn, pkg string
+	}{
+		{"net/http.(*Client).Do", "net/http.(*Client).Do", "net/http"},
+		{"main.main", "main.main", "main"},
+		{"gopkg.in/yaml%2ev3.(*parser).parse", "gopkg.in/yaml.v3.(*parser).parse", "gopkg.in/yaml.v3"},
+		{"example.com/a%2eb%2ec.Func.func1", "example.com/a.b.c.Func.func1", "example.com/a.b.c"},
+		{"runtime", "runtime", ""},
+	} {
+		fn, pkg := splitFunction(tc.in)
+		assert.Equal(t, tc.fn, fn)
+		assert.Equal(t, tc.pkg, pkg)
+	}
+}
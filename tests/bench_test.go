@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fctx"
+	"github.com/Southclaws/fault/fmsg"
+	"github.com/Southclaws/fault/ftag"
+)
+
+var benchErr error
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchErr = fault.New("not found")
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchErr = fault.Wrap(errSentinelStdlib)
+	}
+}
+
+func BenchmarkWrapWithWrappers(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchErr = fault.Wrap(errSentinelStdlib,
+			fmsg.With("cache miss"),
+			ftag.With(ftag.NotFound),
+		)
+	}
+}
+
+func BenchmarkWrapDeep(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := errors.New("root")
+		for j := 0; j < 10; j++ {
+			err = fault.Wrap(err)
+		}
+		benchErr = err
+	}
+}
+
+func BenchmarkFlatten(b *testing.B) {
+	err := errorCaller(4)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = fault.Flatten(err)
+	}
+}
+
+func BenchmarkError(b *testing.B) {
+	err := errorCaller(4)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = err.Error()
+	}
+}
+
+var benchKeys = []int{5, 20, 100}
+
+// benchContext builds a context with n keys by adding one key at a time, which
+// is how middleware stacks and loops usually build up metadata.
+func benchContext(n int) context.Context {
+	ctx := context.Background()
+	for i := 0; i < n; i++ {
+		ctx = fctx.WithMeta(ctx, fmt.Sprintf("key_%d", i), "value")
+	}
+	return ctx
+}
+
+func BenchmarkFctxWithMeta(b *testing.B) {
+	for _, n := range benchKeys {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = benchContext(n)
+			}
+		})
+	}
+}
+
+// BenchmarkFctxWithMetaRead reads the metadata each time a key is added, as a
+// loop which logs with the context as it goes would.
+func BenchmarkFctxWithMetaRead(b *testing.B) {
+	for _, n := range benchKeys {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ctx := context.Background()
+				for j := 0; j < n; j++ {
+					ctx = fctx.WithMeta(ctx, fmt.Sprintf("key_%d", j), "value")
+					_ = fctx.GetMeta(ctx)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFctxWrap(b *testing.B) {
+	for _, n := range benchKeys {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			ctx := benchContext(n)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				benchErr = fctx.Wrap(errSentinelStdlib, ctx, "extra", "value")
+			}
+		})
+	}
+}
+
+func BenchmarkFctxGetMeta(b *testing.B) {
+	for _, n := range benchKeys {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			ctx := benchContext(n)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = fctx.GetMeta(ctx)
+			}
+		})
+	}
+}
+
+func BenchmarkFctxUnwrap(b *testing.B) {
+	for _, n := range benchKeys {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			err := fctx.Wrap(errSentinelStdlib, benchContext(n))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = fctx.Unwrap(err)
+			}
+		})
+	}
+}
+
+func BenchmarkFctxWithStruct(b *testing.B) {
+	ctx := context.Background()
+	u := structUser{ID: 42, Org: structOrg{ID: "o1"}, Tenant: "acme"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = fctx.WithStruct(ctx, u)
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fmsg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainRootStdlibSentinelError(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(1))
+
+	a.Equal(errSentinelStdlib, chain.Root())
+}
+
+func TestChainRootFaultSentinelError(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(2))
+
+	a.Equal(errSentinelFault, chain.Root())
+}
+
+func TestChainRootStdlibErrorfWrappedError(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(5))
+
+	a.Equal(errSentinelStdlib, chain.Root())
+	a.True(errors.Is(chain.Root(), errSentinelStdlib))
+}
+
+func TestChainRootEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(nil)
+
+	a.Nil(chain.Root())
+	a.Empty(chain.Errors())
+	a.Empty(chain.Location())
+}
+
+func TestChainErrors(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(5))
+	errs := chain.Errors()
+
+	a.Len(errs, 2)
+	a.Equal("errorf wrapped: stdlib sentinel error", errs[0].Error())
+	a.Equal("failed to call function", errs[1].Error())
+}
+
+func TestChainLocation(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(1))
+
+	a.Contains(chain.Location(), "test_callers.go:11")
+}
+
+func TestChainFind(t *testing.T) {
+	a := assert.New(t)
+
+	err := fault.Wrap(errorCaller(1), fmsg.With("outer"))
+	chain := fault.Flatten(err)
+
+	first, ok := chain.Find(func(s fault.Step) bool { return s.Message != "" })
+	a.True(ok)
+	a.Equal("stdlib sentinel error", first.Message)
+
+	last, ok := chain.FindLast(func(s fault.Step) bool { return s.Message != "" })
+	a.True(ok)
+	a.Equal("outer", last.Message)
+
+	_, ok = chain.Find(func(s fault.Step) bool { return s.Message == "nothing" })
+	a.False(ok)
+}
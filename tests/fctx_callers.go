@@ -0,0 +1,30 @@
+package tests
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fctx"
+	"github.com/Southclaws/fault/fmsg"
+)
+
+// fctxHandler and fctxService simulate two layers of an application that both
+// wrap errors with context metadata, where the values of some keys differ.
+
+func fctxHandler(ctx context.Context) error {
+	ctx = fctx.WithMeta(ctx, "request_id", "req-1", "user_id", "outer")
+
+	err := fctxService(ctx)
+	if err != nil {
+		return fault.Wrap(err, fctx.With(ctx), fmsg.With("handler failed"))
+	}
+
+	return nil
+}
+
+func fctxService(ctx context.Context) error {
+	ctx = fctx.WithMeta(ctx, "user_id", "inner", "shard", "7")
+
+	return fault.Wrap(errors.New("query failed"), fctx.With(ctx))
+}
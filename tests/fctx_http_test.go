@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Southclaws/fault/fctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaggageMiddleware(t *testing.T) {
+	a := assert.New(t)
+
+	var meta map[string]string
+	h := fctx.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = fctx.GetMeta(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Add("baggage", "request_id=abc, tenant_id = acme;ttl=30")
+	r.Header.Add("baggage", "note=hello%20world%2C%20again,invalid key=1,novalue")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	a.Equal(map[string]string{
+		"request_id": "abc",
+		"tenant_id":  "acme",
+		"note":       "hello world, again",
+	}, meta)
+}
+
+func TestBaggageMiddlewareAllowDeny(t *testing.T) {
+	a := assert.New(t)
+
+	var meta map[string]string
+	h := fctx.Middleware(&fctx.BaggageOptions{
+		Allow: []string{"request_id", "tenant_id"},
+		Deny:  []string{"tenant_id"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = fctx.GetMeta(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("baggage", "request_id=abc,tenant_id=acme,secret=x")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	a.Equal(map[string]string{"request_id": "abc"}, meta)
+}
+
+func TestBaggageMiddlewareKeepsExisting(t *testing.T) {
+	a := assert.New(t)
+
+	var meta map[string]string
+	h := fctx.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = fctx.GetMeta(r.Context())
+	}))
+
+	// the request ID is set by middleware which runs first.
+	withRequestID := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(fctx.WithMeta(r.Context(), "request_id", "server-1")))
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("baggage", "request_id=client-1,tenant_id=acme")
+	withRequestID.ServeHTTP(httptest.NewRecorder(), r)
+
+	a.Equal(map[string]string{
+		"request_id": "server-1",
+		"tenant_id":  "acme",
+	}, meta)
+}
+
+func TestBaggageMiddlewareLimits(t *testing.T) {
+	a := assert.New(t)
+
+	var meta map[string]string
+	h := fctx.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = fctx.GetMeta(r.Context())
+	}))
+
+	members := []string{}
+	for i := 0; i < 1000; i++ {
+		members = append(members, fmt.Sprintf("k%d=v", i))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("baggage", strings.Join(members, ","))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	a.Len(meta, 180)
+	a.Contains(meta, "k179")
+	a.NotContains(meta, "k180")
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("baggage", "a="+strings.Repeat("x", 8000)+",b="+strings.Repeat("x", 500))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	a.Contains(meta, "a")
+	a.NotContains(meta, "b")
+}
+
+func TestBaggageTransport(t *testing.T) {
+	a := assert.New(t)
+
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("baggage")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: fctx.Transport(nil, &fctx.BaggageOptions{
+		Deny: []string{"password"},
+	})}
+
+	ctx := fctx.WithMeta(context.Background(),
+		"request_id", "abc",
+		"note", "a, b;c",
+		"password", "hunter2",
+	)
+	ctx = fctx.WithSensitive(ctx, "email", "user@example.com")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	a.NoError(err)
+	req.Header.Set("baggage", "request_id=old,vendor=x;prop")
+
+	res, err := client.Do(req)
+	a.NoError(err)
+	res.Body.Close()
+
+	a.Equal("note=a%2C%20b%3Bc,request_id=abc,vendor=x;prop", header)
+	a.Equal("request_id=old,vendor=x;prop", req.Header.Get("baggage"), "the original request is not modified")
+}
+
+func TestBaggageRoundTrip(t *testing.T) {
+	a := assert.New(t)
+
+	var meta map[string]string
+	srv := httptest.NewServer(fctx.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta = fctx.GetMeta(r.Context())
+	})))
+	defer srv.Close()
+
+	client := &http.Client{Transport: fctx.Transport(nil, nil)}
+
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc", "tenant_id", "a=b, c")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	a.NoError(err)
+
+	res, err := client.Do(req)
+	a.NoError(err)
+	res.Body.Close()
+
+	a.Equal(map[string]string{"request_id": "abc", "tenant_id": "a=b, c"}, meta)
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/Southclaws/fault/fctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func pprofLabels(ctx context.Context) map[string]string {
+	labels := map[string]string{}
+	pprof.ForLabels(ctx, func(k, v string) bool {
+		labels[k] = v
+		return true
+	})
+	return labels
+}
+
+func TestDo(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "tenant_id", "acme", "route", "/users")
+	ctx = fctx.WithSensitive(ctx, "email", "user@example.com")
+
+	var labels map[string]string
+	fctx.Do(ctx, func(ctx context.Context) {
+		labels = pprofLabels(ctx)
+	})
+	a.Equal(map[string]string{"tenant_id": "acme", "route": "/users"}, labels)
+
+	fctx.Do(ctx, func(ctx context.Context) {
+		labels = pprofLabels(ctx)
+	}, "tenant_id", "email", "missing")
+	a.Equal(map[string]string{"tenant_id": "acme"}, labels)
+
+	a.Empty(pprofLabels(ctx), "labels are only applied within the function")
+}
+
+func TestProfile(t *testing.T) {
+	var labels map[string]string
+	h := fctx.Profile("tenant_id")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		labels = pprofLabels(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(fctx.WithMeta(r.Context(), "tenant_id", "acme", "user_id", "1"))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, map[string]string{"tenant_id": "acme"}, labels)
+}
@@ -3,7 +3,10 @@ package tests
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/Southclaws/fault/fctx"
 	"github.com/kr/pretty"
@@ -183,3 +186,474 @@ func TestWithMetaDifferentMapAddress(t *testing.T) {
 		"The second unwrap result contains all the data merged together.",
 	)
 }
+
+func TestWithMetaReadAgain(t *testing.T) {
+	a := assert.New(t)
+
+	ctx1 := fctx.WithMeta(context.Background(), "key1", "value1")
+	err1 := fctx.Wrap(errors.New("a problem"), ctx1)
+	err2 := fctx.Wrap(err1, fctx.WithMeta(ctx1, "key2", "value2"))
+
+	data1 := fctx.Unwrap(err1)
+	data1["key3"] = "value3"
+
+	a.Equal(map[string]string{"key1": "value1", "key2": "value2"}, fctx.Unwrap(err2))
+	a.Equal(map[string]string{"key1": "value1"}, fctx.Unwrap(err1), "results do not share maps")
+
+	fctx.SetSensitiveKeys("key1")
+	defer fctx.SetSensitiveKeys()
+
+	a.Equal(map[string]string{"key1": "****"}, fctx.Unwrap(err1), "sensitive keys apply to metadata that was already read")
+	a.Equal(map[string]string{"key1": "****"}, fctx.GetMeta(ctx1))
+}
+
+func TestWithMetaSiblingContexts(t *testing.T) {
+	a := assert.New(t)
+
+	parent := fctx.WithMeta(context.Background(), "request_id", "abc")
+	ctx1 := fctx.WithMeta(parent, "user_id", "1")
+	ctx2 := fctx.WithMeta(parent, "user_id", "2", "request_id", "def")
+
+	a.Equal(map[string]string{"request_id": "abc"}, fctx.GetMeta(parent))
+	a.Equal(map[string]string{"request_id": "abc", "user_id": "1"}, fctx.GetMeta(ctx1))
+	a.Equal(map[string]string{"request_id": "def", "user_id": "2"}, fctx.GetMeta(ctx2))
+}
+
+func TestWithMetaLoop(t *testing.T) {
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		ctx = fctx.WithMeta(ctx, "attempt", fmt.Sprint(i))
+	}
+
+	assert.Equal(t, map[string]string{"attempt": "99"}, fctx.GetMeta(ctx))
+}
+
+func TestWithAttrs(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := context.Background()
+	ctx = fctx.WithMeta(ctx, "key", "value")
+	ctx = fctx.WithAttrs(ctx,
+		slog.Int("retries", 3),
+		slog.Duration("timeout", 2*time.Second),
+		slog.Bool("admin", true),
+	)
+
+	err := fctx.Wrap(errors.New("a problem"), ctx)
+
+	a.Equal([]slog.Attr{
+		slog.Bool("admin", true),
+		slog.String("key", "value"),
+		slog.Int("retries", 3),
+		slog.Duration("timeout", 2*time.Second),
+	}, fctx.UnwrapAttrs(err))
+
+	a.Equal(map[string]string{
+		"admin":   "true",
+		"key":     "value",
+		"retries": "3",
+		"timeout": "2s",
+	}, fctx.Unwrap(err))
+}
+
+func TestWithAttrsOverwrite(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := context.Background()
+	ctx = fctx.WithAttrs(ctx, slog.Int("key", 1))
+	ctx = fctx.WithMeta(ctx, "key", "two")
+
+	a.Equal([]slog.Attr{slog.String("key", "two")}, fctx.GetAttrs(ctx))
+	a.Equal(map[string]string{"key": "two"}, fctx.GetMeta(ctx))
+}
+
+func TestWrapAttrs(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "key", "value")
+	err := fctx.WrapAttrs(errors.New("a problem"), ctx, slog.Int64("id", 42), slog.Attr{})
+
+	a.Equal([]slog.Attr{
+		slog.Int64("id", 42),
+		slog.String("key", "value"),
+	}, fctx.UnwrapAttrs(err))
+}
+
+func TestGetAttrsEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	a.Nil(fctx.GetAttrs(context.Background()))
+	a.Nil(fctx.GetAttrs(nil))
+	a.Nil(fctx.UnwrapAttrs(errors.New("a problem")))
+	a.Nil(fctx.WithAttrs(nil, slog.Int("key", 1)))
+}
+
+func TestAppend(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "shard", "eu-1", "user_id", "123")
+	ctx = fctx.Append(ctx, "shard", "eu-2")
+	ctx = fctx.Append(ctx, "flag", "beta", "flag", "dark_mode")
+
+	a.Equal(map[string][]string{
+		"shard":   {"eu-1", "eu-2"},
+		"flag":    {"beta", "dark_mode"},
+		"user_id": {"123"},
+	}, fctx.GetMetaValues(ctx))
+
+	a.Equal(map[string]string{
+		"shard":   "eu-1,eu-2",
+		"flag":    "beta,dark_mode",
+		"user_id": "123",
+	}, fctx.GetMeta(ctx))
+
+	err := fctx.Wrap(errors.New("a problem"), fctx.Append(ctx, "shard", "us-1"))
+	a.Equal([]string{"eu-1", "eu-2", "us-1"}, fctx.UnwrapValues(err)["shard"])
+	a.Equal("eu-1,eu-2,us-1", fctx.Unwrap(err)["shard"])
+}
+
+func TestAppendDoesNotShareValues(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.Append(context.Background(), "shard", "a", "shard", "b")
+	ctx1 := fctx.Append(ctx, "shard", "c")
+	ctx2 := fctx.Append(ctx, "shard", "d")
+
+	a.Equal([]string{"a", "b"}, fctx.GetMetaValues(ctx)["shard"])
+	a.Equal([]string{"a", "b", "c"}, fctx.GetMetaValues(ctx1)["shard"])
+	a.Equal([]string{"a", "b", "d"}, fctx.GetMetaValues(ctx2)["shard"])
+}
+
+func TestAppendThenOverwrite(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.Append(context.Background(), "shard", "a", "shard", "b")
+	ctx = fctx.WithMeta(ctx, "shard", "c")
+
+	a.Equal([]string{"c"}, fctx.GetMetaValues(ctx)["shard"])
+}
+
+func TestAppendAttrs(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.AppendAttrs(context.Background(), slog.Int("retry", 1))
+	ctx = fctx.AppendAttrs(ctx, slog.Int("retry", 2))
+
+	attrs := fctx.GetAttrs(ctx)
+	a.Len(attrs, 1)
+	a.Equal([]any{int64(1), int64(2)}, attrs[0].Value.Any())
+	a.Equal("1,2", fctx.GetMeta(ctx)["retry"])
+}
+
+func TestLayers(t *testing.T) {
+	a := assert.New(t)
+
+	err := fctxHandler(context.Background())
+	layers := fctx.Layers(err)
+
+	a.Len(layers, 2)
+
+	a.Equal("<fctx>", layers[0].Step.Message)
+	a.Contains(layers[0].Step.Location, "fctx_callers.go:20")
+	a.Equal(map[string]string{"request_id": "req-1", "user_id": "outer"}, layers[0].Meta)
+
+	a.Equal("<fctx>", layers[1].Step.Message)
+	a.Contains(layers[1].Step.Location, "fctx_callers.go:29")
+	a.Equal(map[string]string{"request_id": "req-1", "user_id": "inner", "shard": "7"}, layers[1].Meta)
+}
+
+func TestLayersWithoutFault(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "key", "value")
+	err := fctx.Wrap(errors.New("a problem"), ctx)
+	layers := fctx.Layers(err)
+
+	a.Len(layers, 1)
+	a.Empty(layers[0].Step.Location)
+	a.Equal(map[string]string{"key": "value"}, layers[0].Meta)
+}
+
+func TestLayersEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	a.Nil(fctx.Layers(nil))
+	a.Nil(fctx.Layers(errors.New("a problem")))
+}
+
+func TestUnwrapWithPolicies(t *testing.T) {
+	a := assert.New(t)
+
+	err := fctxHandler(context.Background())
+
+	a.Equal(fctx.Unwrap(err), fctx.UnwrapWith(err, fctx.InnermostWins))
+
+	a.Equal(map[string]string{
+		"request_id": "req-1",
+		"user_id":    "inner",
+		"shard":      "7",
+	}, fctx.UnwrapWith(err, fctx.InnermostWins))
+
+	a.Equal(map[string]string{
+		"request_id": "req-1",
+		"user_id":    "outer",
+		"shard":      "7",
+	}, fctx.UnwrapWith(err, fctx.OutermostWins))
+
+	a.Equal(map[string]string{
+		"request_id": "req-1",
+		"user_id":    "inner",
+		"user_id_2":  "outer",
+		"shard":      "7",
+	}, fctx.UnwrapWith(err, fctx.KeepAll))
+}
+
+func TestUnwrapWithKeepAllCollision(t *testing.T) {
+	ctx := fctx.WithMeta(context.Background(), "user_id", "1", "user_id_2", "other")
+	err := fctx.Wrap(errors.New("a problem"), ctx)
+	err = fctx.Wrap(err, fctx.WithMeta(context.Background(), "user_id", "2"))
+	err = fctx.Wrap(err, fctx.WithMeta(context.Background(), "user_id", "3"))
+
+	assert.Equal(t, map[string]string{
+		"user_id":   "1",
+		"user_id_2": "other",
+		"user_id_3": "2",
+		"user_id_4": "3",
+	}, fctx.UnwrapWith(err, fctx.KeepAll))
+}
+
+func TestUnwrapWithEmpty(t *testing.T) {
+	assert.Nil(t, fctx.UnwrapWith(errors.New("a problem"), fctx.KeepAll))
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc")
+	ctx = fctx.WithAttrs(ctx, slog.Int("retries", 3))
+	ctx = fctx.Append(ctx, "shard", "eu-1", "shard", "eu-2")
+
+	data := fctx.Snapshot(ctx)
+	a.JSONEq(`{"request_id":"abc","retries":"3","shard":["eu-1","eu-2"]}`, string(data))
+
+	job, err := fctx.Restore(context.Background(), data)
+	a.NoError(err)
+	a.Equal(fctx.GetMetaValues(ctx), fctx.GetMetaValues(job))
+
+	job = fctx.WithMeta(job, "job_id", "j1")
+	wrapped := fctx.Wrap(errors.New("job failed"), job)
+
+	a.True(fctx.IsRestored(wrapped))
+	a.Equal(map[string]string{
+		"request_id": "abc",
+		"retries":    "3",
+		"shard":      "eu-1,eu-2",
+		"job_id":     "j1",
+	}, fctx.Unwrap(wrapped))
+
+	layers := fctx.Layers(wrapped)
+	a.Len(layers, 1)
+	a.True(layers[0].Restored)
+}
+
+func TestSnapshotEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	a.Nil(fctx.Snapshot(context.Background()))
+
+	ctx, err := fctx.Restore(context.Background(), nil)
+	a.NoError(err)
+	a.Nil(fctx.GetMeta(ctx))
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	_, err := fctx.Restore(context.Background(), []byte(`{"a":1}`))
+	assert.Error(t, err)
+}
+
+func TestIsRestoredNotRestored(t *testing.T) {
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc")
+
+	assert.False(t, fctx.IsRestored(fctx.Wrap(errors.New("a problem"), ctx)))
+	assert.False(t, fctx.IsRestored(errors.New("a problem")))
+}
+
+func TestWithSensitive(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc")
+	ctx = fctx.WithSensitive(ctx, "email", "user@example.com")
+	err := fctx.Wrap(errors.New("a problem"), ctx)
+
+	a.Equal(map[string]string{"request_id": "abc", "email": "****"}, fctx.GetMeta(ctx))
+	a.Equal(map[string]string{"request_id": "abc", "email": "****"}, fctx.Unwrap(err))
+	a.Equal("user@example.com", fctx.GetMetaRedacted(ctx, fctx.Raw)["email"])
+	a.Equal("user@example.com", fctx.UnwrapRedacted(err, fctx.Raw)["email"])
+
+	hashed := fctx.UnwrapRedacted(err, fctx.Hashed)
+	a.Regexp(`^sha256:[0-9a-f]{16}$`, hashed["email"])
+	a.Equal(hashed["email"], fctx.GetMetaRedacted(ctx, fctx.Hashed)["email"], "hashes are stable")
+	a.Equal("abc", hashed["request_id"])
+
+	a.Equal([]slog.Attr{slog.String("email", "****"), slog.String("request_id", "abc")}, fctx.UnwrapAttrs(err))
+}
+
+func TestWithSensitiveStaysSensitive(t *testing.T) {
+	ctx := fctx.WithSensitive(context.Background(), "token", "secret")
+	ctx = fctx.WithMeta(ctx, "token", "other")
+
+	assert.Equal(t, "****", fctx.GetMeta(ctx)["token"])
+}
+
+func TestSetSensitiveKeys(t *testing.T) {
+	a := assert.New(t)
+
+	fctx.SetSensitiveKeys("remote_ip")
+	defer fctx.SetSensitiveKeys()
+
+	ctx := fctx.WithMeta(context.Background(), "remote_ip", "127.0.0.1", "user_id", "1")
+	ctx = fctx.Append(ctx, "remote_ip", "10.0.0.1")
+
+	a.Equal(map[string]string{"remote_ip": "****,****", "user_id": "1"}, fctx.GetMeta(ctx))
+	a.Equal("127.0.0.1,10.0.0.1", fctx.GetMetaRedacted(ctx, fctx.Raw)["remote_ip"])
+}
+
+func TestSetRedaction(t *testing.T) {
+	fctx.SetRedaction(fctx.Raw)
+	defer fctx.SetRedaction(fctx.Masked)
+
+	ctx := fctx.WithSensitive(context.Background(), "email", "user@example.com")
+
+	assert.Equal(t, "user@example.com", fctx.GetMeta(ctx)["email"])
+}
+
+func TestSnapshotSensitive(t *testing.T) {
+	a := assert.New(t)
+
+	fctx.SetSensitiveKeys("remote_ip")
+	defer fctx.SetSensitiveKeys()
+
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc", "remote_ip", "127.0.0.1")
+	ctx = fctx.WithSensitive(ctx, "email", "user@example.com")
+
+	a.JSONEq(`{"request_id":"abc"}`, string(fctx.Snapshot(ctx)))
+
+	fctx.SetRedaction(fctx.Raw)
+	defer fctx.SetRedaction(fctx.Masked)
+
+	a.JSONEq(`{"request_id":"abc"}`, string(fctx.Snapshot(ctx)), "sensitive metadata is left out even when raw values are read")
+	a.Nil(fctx.Snapshot(fctx.WithSensitive(context.Background(), "email", "user@example.com")))
+}
+
+func TestOmitted(t *testing.T) {
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc")
+	ctx = fctx.WithSensitive(ctx, "email", "user@example.com")
+	err := fctx.Wrap(errors.New("a problem"), ctx)
+
+	assert.Equal(t, map[string]string{"request_id": "abc"}, fctx.GetMetaRedacted(ctx, fctx.Omitted))
+	assert.Equal(t, map[string][]string{"request_id": {"abc"}}, fctx.UnwrapValuesRedacted(err, fctx.Omitted))
+}
+
+type structOrg struct {
+	ID   string `fctx:"id"`
+	Plan string `fctx:"plan,omitempty"`
+}
+
+type structTenant string
+
+func (t structTenant) Metadata() map[string]string {
+	return map[string]string{"tenant": string(t)}
+}
+
+type structUser struct {
+	ID       int          `fctx:"user_id"`
+	Email    string       `fctx:"email,omitempty"`
+	Name     string       // not tagged
+	Password string       `fctx:"-"`
+	Org      structOrg    `fctx:"org"`
+	Parent   *structOrg   `fctx:"parent"`
+	Tenant   structTenant `fctx:"t"`
+	Created  time.Time    `fctx:"created"`
+	private  string       `fctx:"private"`
+}
+
+func TestWithStruct(t *testing.T) {
+	a := assert.New(t)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx := fctx.WithStruct(context.Background(), &structUser{
+		ID:       42,
+		Name:     "Southclaws",
+		Password: "hunter2",
+		Org:      structOrg{ID: "o1"},
+		Tenant:   "acme",
+		Created:  created,
+		private:  "x",
+	})
+
+	a.Equal(map[string]string{
+		"user_id":  "42",
+		"org_id":   "o1",
+		"t_tenant": "acme",
+		"created":  created.String(),
+	}, fctx.GetMeta(ctx))
+
+	attrs := fctx.GetAttrs(ctx)
+	a.Equal(slog.KindInt64, attrs[len(attrs)-1].Value.Kind(), "user_id keeps its type")
+}
+
+func TestWithStructNestedPointer(t *testing.T) {
+	ctx := fctx.WithStruct(context.Background(), structUser{
+		ID:     1,
+		Email:  "user@example.com",
+		Parent: &structOrg{ID: "p1", Plan: "pro"},
+	})
+
+	meta := fctx.GetMeta(ctx)
+	assert.Equal(t, "user@example.com", meta["email"])
+	assert.Equal(t, "p1", meta["parent_id"])
+	assert.Equal(t, "pro", meta["parent_plan"])
+	assert.NotContains(t, meta, "org_plan")
+}
+
+func TestWithStructMetadata(t *testing.T) {
+	ctx := fctx.WithStruct(context.Background(), structTenant("acme"))
+
+	assert.Equal(t, map[string]string{"tenant": "acme"}, fctx.GetMeta(ctx))
+}
+
+type structAccount struct {
+	ID     int           `fctx:"account_id"`
+	Owner  fctx.Metadata `fctx:"owner"`
+	Tenant *structTenant `fctx:"t"`
+	Parent *structOrg    `fctx:"parent"`
+}
+
+func TestWithStructNilInterface(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithStruct(context.Background(), structAccount{ID: 1})
+	a.Equal(map[string]string{"account_id": "1"}, fctx.GetMeta(ctx))
+
+	ctx = fctx.WithStruct(context.Background(), structAccount{ID: 1, Owner: structTenant("acme")})
+	a.Equal(map[string]string{"account_id": "1", "owner_tenant": "acme"}, fctx.GetMeta(ctx))
+}
+
+func TestWithStructNilPointer(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithStruct(context.Background(), &structAccount{ID: 1})
+	a.Equal(map[string]string{"account_id": "1"}, fctx.GetMeta(ctx))
+
+	tenant := structTenant("acme")
+	ctx = fctx.WithStruct(context.Background(), &structAccount{ID: 1, Tenant: &tenant})
+	a.Equal(map[string]string{"account_id": "1", "t_tenant": "acme"}, fctx.GetMeta(ctx))
+}
+
+func TestWithStructNotStruct(t *testing.T) {
+	a := assert.New(t)
+
+	a.Nil(fctx.GetMeta(fctx.WithStruct(context.Background(), 42)))
+	a.Nil(fctx.GetMeta(fctx.WithStruct(context.Background(), nil)))
+	a.Nil(fctx.GetMeta(fctx.WithStruct(context.Background(), (*structUser)(nil))))
+}
@@ -0,0 +1,342 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fctx"
+	"github.com/Southclaws/fault/fjson"
+	"github.com/Southclaws/fault/fmsg"
+	"github.com/Southclaws/fault/ftag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetPathMode(fault.PathModule)
+	defer fault.SetPathMode(fault.PathAbsolute)
+
+	b, err := json.Marshal(errorCaller(1))
+	a.NoError(err)
+
+	a.JSONEq(`{
+		"message": "failed to call function: stdlib sentinel error",
+		"chain": [
+			{"message": "stdlib sentinel error"},
+			{
+				"location": "tests/test_callers.go:29",
+				"function": "github.com/Southclaws/fault/tests.errorProducerFromRootCause",
+				"package": "github.com/Southclaws/fault/tests",
+				"file": "tests/test_callers.go",
+				"line": 29
+			},
+			{
+				"location": "tests/test_callers.go:20",
+				"message": "failed to call function",
+				"function": "github.com/Southclaws/fault/tests.errorCallerFromMiddleOfChain",
+				"package": "github.com/Southclaws/fault/tests",
+				"file": "tests/test_callers.go",
+				"line": 20
+			},
+			{
+				"location": "tests/test_callers.go:11",
+				"function": "github.com/Southclaws/fault/tests.errorCaller",
+				"package": "github.com/Southclaws/fault/tests",
+				"file": "tests/test_callers.go",
+				"line": 11
+			}
+		]
+	}`, string(b))
+}
+
+func TestMarshalJSONFundamental(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetPathMode(fault.PathBase)
+	defer fault.SetPathMode(fault.PathAbsolute)
+
+	b, err := json.Marshal(errSentinelFault)
+	a.NoError(err)
+
+	a.JSONEq(`{
+		"message": "fault sentinel error",
+		"chain": [
+			{
+				"location": "root.go:15",
+				"message": "fault sentinel error",
+				"function": "github.com/Southclaws/fault/tests.init",
+				"package": "github.com/Southclaws/fault/tests",
+				"file": "root.go",
+				"line": 15
+			}
+		]
+	}`, string(b))
+}
+
+func TestMarshalJSONChain(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(1))
+
+	b, err := json.Marshal(chain)
+	a.NoError(err)
+
+	var decoded fault.Chain
+	a.NoError(json.Unmarshal(b, &decoded))
+	a.Len(decoded, 4)
+	a.Equal(chain[2].Message, decoded[2].Message)
+	a.Equal(chain[2].Location, decoded[2].Location)
+}
+
+func TestFJSONEncode(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "user_id", "123")
+	err := fault.Wrap(errors.New("no rows"),
+		fctx.With(ctx),
+		ftag.With(ftag.NotFound),
+		fmsg.WithDesc("user not found", "The user could not be found."),
+	)
+
+	d := fjson.Encode(err, fjson.WithAll())
+
+	a.Equal("user not found: no rows", d.Message)
+	a.Len(d.Chain, 4)
+	a.Equal([]ftag.Kind{ftag.NotFound}, d.Tags)
+	a.Equal(fjson.Meta{"user_id": {"123"}}, d.Meta)
+	a.Equal([]string{"The user could not be found."}, d.Issues)
+}
+
+func TestFJSONMultiValuedMeta(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "user_id", "123")
+	ctx = fctx.Append(ctx, "shard", "eu-1", "shard", "eu-2")
+	err := fault.Wrap(errors.New("no rows"), fctx.With(ctx))
+
+	b, merr := fjson.Marshal(err, fjson.WithMeta())
+	a.NoError(merr)
+
+	var m map[string]any
+	a.NoError(json.Unmarshal(b, &m))
+	a.Equal(map[string]any{
+		"user_id": "123",
+		"shard":   []any{"eu-1", "eu-2"},
+	}, m["meta"])
+
+	d, uerr := fjson.Unmarshal(b)
+	a.NoError(uerr)
+
+	decoded := fjson.Decode(d)
+	a.Equal(map[string][]string{
+		"user_id": {"123"},
+		"shard":   {"eu-1", "eu-2"},
+	}, fctx.UnwrapValues(decoded))
+}
+
+func TestFJSONSensitiveMeta(t *testing.T) {
+	a := assert.New(t)
+
+	fctx.SetRedaction(fctx.Raw)
+	defer fctx.SetRedaction(fctx.Masked)
+
+	ctx := fctx.WithMeta(context.Background(), "user_id", "123")
+	ctx = fctx.WithSensitive(ctx, "email", "user@example.com")
+	err := fault.Wrap(errors.New("no rows"), fctx.With(ctx))
+
+	b, merr := fjson.Marshal(err, fjson.WithMeta())
+	a.NoError(merr)
+	a.NotContains(string(b), "email")
+
+	d, uerr := fjson.Unmarshal(b)
+	a.NoError(uerr)
+	a.Equal(map[string]string{"user_id": "123"}, fctx.Unwrap(fjson.Decode(d)))
+}
+
+func TestFJSONMarshalWithoutOptions(t *testing.T) {
+	a := assert.New(t)
+
+	err := fault.Wrap(errors.New("no rows"), ftag.With(ftag.NotFound))
+
+	b, merr := fjson.Marshal(err)
+	a.NoError(merr)
+
+	var m map[string]any
+	a.NoError(json.Unmarshal(b, &m))
+	a.Equal("no rows", m["message"])
+	a.Contains(m, "chain")
+	a.NotContains(m, "tags")
+	a.NotContains(m, "meta")
+	a.NotContains(m, "issues")
+}
+
+func TestFJSONNil(t *testing.T) {
+	assert.Nil(t, fjson.Encode(nil))
+}
+
+func TestFJSONDecode(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "user_id", "123", "request_id", "abc")
+	original := fault.Wrap(errorCaller(4),
+		fctx.With(ctx),
+		ftag.With(ftag.Internal),
+		fmsg.WithDesc("lookup failed", "The user could not be loaded."),
+	)
+	original = fault.Wrap(original,
+		ftag.With(ftag.NotFound),
+		fmsg.WithDesc("get user", "Please try again."),
+	)
+
+	b, err := fjson.Marshal(original, fjson.WithAll())
+	a.NoError(err)
+
+	d, err := fjson.Unmarshal(b)
+	a.NoError(err)
+
+	decoded := fjson.Decode(d)
+
+	a.Equal(original.Error(), decoded.Error())
+	a.Equal(ftag.NotFound, ftag.Get(decoded))
+	a.Equal([]ftag.Kind{ftag.NotFound, ftag.Internal}, ftag.GetAll(decoded))
+	a.Equal(map[string]string{"user_id": "123", "request_id": "abc"}, fctx.Unwrap(decoded))
+	a.Equal("Please try again. The user could not be loaded.", fmsg.GetIssue(decoded))
+
+	originalChain := fault.Flatten(original)
+	decodedChain := fault.Flatten(decoded)
+	a.Len(decodedChain, len(originalChain))
+	for i := range decodedChain {
+		a.True(decodedChain[i].Remote)
+		a.Equal(originalChain[i].Message, decodedChain[i].Message)
+		a.Equal(originalChain[i].Location, decodedChain[i].Location)
+		a.Equal(originalChain[i].Line, decodedChain[i].Line)
+	}
+
+	a.Equal("fault root cause error", decodedChain.Root().Error())
+	a.Equal(fmt.Sprintf("%+v", original), fmt.Sprintf("%+v", decoded))
+}
+
+func TestFJSONDecodeWrapped(t *testing.T) {
+	a := assert.New(t)
+
+	b, err := fjson.Marshal(fault.New("remote problem"))
+	a.NoError(err)
+
+	d, err := fjson.Unmarshal(b)
+	a.NoError(err)
+
+	wrapped := fault.Wrap(fjson.Decode(d), fmsg.With("calling remote"))
+	chain := fault.Flatten(wrapped)
+
+	a.Equal("calling remote: remote problem", wrapped.Error())
+	a.Len(chain, 2)
+	a.Equal("remote problem", chain[0].Message)
+	a.True(chain[0].Remote)
+	a.Equal("calling remote", chain[1].Message)
+	a.False(chain[1].Remote)
+	a.Contains(chain[1].Location, "fjson_test.go")
+}
+
+func TestFJSONDecodeEmpty(t *testing.T) {
+	a := assert.New(t)
+
+	a.Nil(fjson.Decode(nil))
+	a.Nil(fjson.Decode(&fjson.Document{}))
+
+	err := fjson.Decode(&fjson.Document{Message: "no chain"})
+	a.Equal("no chain", err.Error())
+}
+
+func TestFJSONDecodeInvalid(t *testing.T) {
+	_, err := fjson.Unmarshal([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestFJSONHTTP(t *testing.T) {
+	a := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := fctx.WithMeta(r.Context(), "request_id", "req-1")
+		err := fault.Wrap(fault.New("row missing"),
+			fctx.With(ctx),
+			ftag.With(ftag.NotFound),
+			fmsg.WithDesc("user lookup", "No such user."),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(fjson.Encode(err, fjson.WithAll()))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	a.NoError(err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	a.NoError(err)
+
+	d, err := fjson.Unmarshal(body)
+	a.NoError(err)
+
+	err = fault.Wrap(fjson.Decode(d), fmsg.With("calling user service"))
+
+	a.Equal("calling user service: user lookup: row missing", err.Error())
+	a.Equal(ftag.NotFound, ftag.Get(err))
+	a.Equal(map[string]string{"request_id": "req-1"}, fctx.Unwrap(err))
+	a.Equal("No such user.", fmsg.GetIssue(err))
+
+	chain := fault.Flatten(err)
+	a.True(chain[0].Remote)
+	a.Equal("row missing", chain[0].Message)
+	a.Contains(chain[0].Location, "fjson_test.go")
+	a.False(chain[len(chain)-1].Remote)
+}
+
+func TestFJSONFieldIssues(t *testing.T) {
+	a := assert.New(t)
+
+	err := fault.Wrap(errors.New("validation failed"),
+		fmsg.WithFields("invalid address",
+			fmsg.FieldIssue{Field: "address.postcode", Message: "Enter a valid postcode.", Code: "format"},
+			fmsg.FieldIssue{Field: "address.city", Message: "Enter a city."},
+		),
+	)
+
+	b, merr := fjson.Marshal(err, fjson.WithIssues())
+	a.NoError(merr)
+	a.Contains(string(b), `"fields":{"address.city":[{"field":"address.city","message":"Enter a city."}]`)
+
+	d, uerr := fjson.Unmarshal(b)
+	a.NoError(uerr)
+
+	a.Equal(fmsg.GetFieldIssues(err), fmsg.GetFieldIssues(fjson.Decode(d)))
+}
+
+func TestFJSONDetails(t *testing.T) {
+	a := assert.New(t)
+
+	err := fault.Wrap(errors.New("no rows"),
+		fmsg.WithDetail("user not found", fmsg.Detail{Code: "USR-404", Message: "Cannot find the requested user."}),
+	)
+	err = fault.Wrap(err, fmsg.WithDesc("load profile", "Your profile could not be loaded."))
+
+	b, merr := fjson.Marshal(err, fjson.WithIssues())
+	a.NoError(merr)
+	a.Contains(string(b), `"details":[{"message":"Your profile could not be loaded."},{"code":"USR-404","message":"Cannot find the requested user."}]`)
+
+	d, uerr := fjson.Unmarshal(b)
+	a.NoError(uerr)
+
+	decoded := fjson.Decode(d)
+	a.Equal(fmsg.GetDetails(err), fmsg.GetDetails(decoded))
+	a.Equal("USR-404", fmsg.GetCode(decoded))
+}
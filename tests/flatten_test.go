@@ -1,9 +1,13 @@
 package tests
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fctx"
 	"github.com/Southclaws/fault/fmsg"
 	"github.com/stretchr/testify/assert"
 )
@@ -221,3 +225,49 @@ func TestFlattenStdlibErrorfWrappedExternallyWrappedErrorBrokenChain(t *testing.
 	e2 := chain[2]
 	a.Equal("failed to query", e2.Message)
 }
+
+func TestFlattenStepFields(t *testing.T) {
+	a := assert.New(t)
+
+	err := errorCaller(4)
+	chain := fault.Flatten(err)
+
+	e0 := chain[0]
+	a.Equal("github.com/Southclaws/fault/tests.rootCause", e0.Function)
+	a.Equal("github.com/Southclaws/fault/tests", e0.Package)
+	a.Contains(e0.File, "fault/tests/root.go")
+	a.Equal(28, e0.Line)
+	a.Equal(fmt.Sprintf("%s:%d", e0.File, e0.Line), e0.Location)
+
+	e2 := chain[2]
+	a.Equal("github.com/Southclaws/fault/tests.errorCallerFromMiddleOfChain", e2.Function)
+	a.Equal("github.com/Southclaws/fault/tests", e2.Package)
+	a.Equal(20, e2.Line)
+}
+
+func TestFlattenStepFieldsNoLocation(t *testing.T) {
+	a := assert.New(t)
+
+	err := errorCaller(1)
+	chain := fault.Flatten(err)
+
+	e0 := chain[0]
+	a.Empty(e0.Location)
+	a.Empty(e0.Function)
+	a.Empty(e0.Package)
+	a.Empty(e0.File)
+	a.Zero(e0.Line)
+}
+
+func TestStepPlaceholder(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "user_id", "123")
+	chain := fault.Flatten(fault.Wrap(errors.New("no rows"), fctx.With(ctx), fmsg.With("user not found")))
+
+	a.Len(chain, 3)
+	a.False(chain[0].Placeholder())
+	a.True(chain[1].Placeholder())
+	a.Equal("<fctx>", chain[1].Message)
+	a.False(chain[2].Placeholder())
+}
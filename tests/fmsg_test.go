@@ -1,9 +1,14 @@
 package tests
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"testing/fstest"
 
+	"github.com/Southclaws/fault/fctx"
 	"github.com/Southclaws/fault/fmsg"
 	"github.com/stretchr/testify/assert"
 )
@@ -44,3 +49,293 @@ func TestWithManySlice(t *testing.T) {
 	assert.Len(t, out, 3)
 	assert.Equal(t, []string{"Your reply draft has been saved however we could not publish it.", "Unable to reply to post.", "The post was not found."}, out)
 }
+
+// unmarshalProperties decodes "a.b = value" lines into nested objects, it stands
+// in for another format such as YAML.
+func unmarshalProperties(data []byte, v any) error {
+	root := map[string]any{}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("invalid line %q", line)
+		}
+
+		m := root
+		parts := strings.Split(strings.TrimSpace(key), ".")
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := m[p].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[p] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = strings.TrimSpace(value)
+	}
+
+	*v.(*map[string]any) = root
+	return nil
+}
+
+func testCatalog(t *testing.T) *fmsg.Catalog {
+	c := fmsg.NewCatalog("en")
+
+	assert.NoError(t, c.LoadJSON("en", []byte(`{
+		"user": {"not_found": "Cannot find the user {name}."},
+		"cart.items": {"zero": "Your cart is empty.", "one": "Your cart has {count} item.", "other": "Your cart has {count} items."},
+		"only.english": "Only in English."
+	}`)))
+
+	assert.NoError(t, c.Load("pt", []byte(`
+user.not_found = Não foi possível encontrar o usuário {name}.
+cart.items.one = Seu carrinho tem {count} item.
+cart.items.other = Seu carrinho tem {count} itens.
+`), unmarshalProperties))
+
+	c.Add("pt-BR", "user.not_found", "Não achamos o usuário {name}.")
+
+	return c
+}
+
+func TestCatalogRender(t *testing.T) {
+	a := assert.New(t)
+	c := testCatalog(t)
+
+	for _, tc := range []struct {
+		locale, key string
+		args        fmsg.Args
+		want        string
+	}{
+		{"en", "user.not_found", fmsg.Args{"name": "Southclaws"}, "Cannot find the user Southclaws."},
+		{"pt", "user.not_found", fmsg.Args{"name": "Southclaws"}, "Não foi possível encontrar o usuário Southclaws."},
+		{"pt_BR", "user.not_found", fmsg.Args{"name": "Southclaws"}, "Não achamos o usuário Southclaws."},
+		{"pt-PT", "user.not_found", fmsg.Args{"name": "Southclaws"}, "Não foi possível encontrar o usuário Southclaws."},
+		{"pt", "only.english", nil, "Only in English."},
+		{"fr", "user.not_found", nil, "Cannot find the user {name}."},
+		{"en", "cart.items", fmsg.Args{"count": 0}, "Your cart is empty."},
+		{"en", "cart.items", fmsg.Args{"count": 1}, "Your cart has 1 item."},
+		{"en", "cart.items", fmsg.Args{"count": 3}, "Your cart has 3 items."},
+		{"pt", "cart.items", fmsg.Args{"count": int64(0)}, "Seu carrinho tem 0 itens."},
+		{"pt", "cart.items", fmsg.Args{"count": 1}, "Seu carrinho tem 1 item."},
+	} {
+		got, ok := c.Render(tc.locale, tc.key, tc.args)
+		a.True(ok, tc.key)
+		a.Equal(tc.want, got)
+	}
+
+	_, ok := c.Render("en", "missing", nil)
+	a.False(ok)
+}
+
+func TestCatalogPluralRule(t *testing.T) {
+	c := fmsg.NewCatalog("en")
+	c.AddPlural("pl", "files", map[string]string{"one": "{count} plik", "few": "{count} pliki", "many": "{count} plików"})
+	c.SetPluralRule("pl", func(n int) string {
+		switch {
+		case n == 1:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+			return "few"
+		default:
+			return "many"
+		}
+	})
+
+	for n, want := range map[int]string{1: "1 plik", 3: "3 pliki", 5: "5 plików", 22: "22 pliki"} {
+		got, _ := c.Render("pl", "files", fmsg.Args{"count": n})
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestCatalogLoadFS(t *testing.T) {
+	a := assert.New(t)
+
+	c := fmsg.NewCatalog("en")
+	a.NoError(c.LoadFS(fstest.MapFS{
+		"locales/en.json":       {Data: []byte(`{"greeting": "Hello"}`)},
+		"locales/de.properties": {Data: []byte(`greeting = Hallo`)},
+		"locales/fr.props":      {Data: []byte(`greeting = Bonjour`)},
+		"locales/it.yaml":       {Data: []byte(`greeting: Ciao`)},
+		"locales/README":        {Data: []byte(`ignored`)},
+		"other/es.json":         {Data: []byte(`{"greeting": "Hola"}`)},
+	}, "locales",
+		fmsg.Format{Ext: ".properties", Unmarshal: unmarshalProperties},
+		fmsg.Format{Ext: ".props", Unmarshal: unmarshalProperties},
+	))
+
+	for locale, want := range map[string]string{"en": "Hello", "de": "Hallo", "fr": "Bonjour", "it": "Hello", "es": "Hello"} {
+		got, _ := c.Render(locale, "greeting", nil)
+		a.Equal(want, got)
+	}
+
+	err := c.LoadFS(fstest.MapFS{
+		"locales/bad.json": {Data: []byte(`{"greeting": 1}`)},
+	}, "locales")
+	a.EqualError(err, `bad.json: message "greeting" must be a string or an object, not float64`)
+}
+
+func TestGetIssueLocalized(t *testing.T) {
+	a := assert.New(t)
+
+	err := fmsg.WithKey("user not found", "user.not_found", fmsg.Args{"name": "Southclaws"})(errors.New("no rows"))
+	err = fmsg.Wrap(err, "get user", "Please try again.")
+
+	a.Equal("Please try again. user.not_found", fmsg.GetIssue(err), "the key is used without a catalog")
+
+	fmsg.SetCatalog(testCatalog(t))
+	defer fmsg.SetCatalog(nil)
+
+	a.Equal("Please try again. Cannot find the user Southclaws.", fmsg.GetIssue(err))
+	a.Equal("Please try again. Não achamos o usuário Southclaws.", fmsg.GetIssueLocalized(err, "pt-BR"))
+	a.Equal([]string{"Please try again.", "Cannot find the user Southclaws."}, fmsg.GetIssuesLocalized(err, "fr"))
+	a.Equal("user not found", err.(interface{ Unwrap() error }).Unwrap().Error())
+}
+
+func TestGetFieldIssues(t *testing.T) {
+	a := assert.New(t)
+
+	err := fmsg.WithFields("invalid address",
+		fmsg.FieldIssue{Field: "address.postcode", Message: "Enter a valid postcode.", Code: "format"},
+		fmsg.FieldIssue{Field: "address.city", Message: "Enter a city.", Code: "required"},
+	)(errors.New("validation failed"))
+	err = fmsg.WithFields("invalid order",
+		fmsg.FieldIssue{Field: "items[3].qty", Message: "Enter a quantity of at least 1."},
+		fmsg.FieldIssue{Field: "address.postcode", Message: "We don't deliver to this postcode.", Code: "unavailable"},
+	)(err)
+	err = fmsg.Wrap(err, "create order", "Your order could not be placed.")
+
+	a.Equal(map[string][]fmsg.FieldIssue{
+		"address.postcode": {
+			{Field: "address.postcode", Message: "We don't deliver to this postcode.", Code: "unavailable"},
+			{Field: "address.postcode", Message: "Enter a valid postcode.", Code: "format"},
+		},
+		"address.city": {
+			{Field: "address.city", Message: "Enter a city.", Code: "required"},
+		},
+		"items[3].qty": {
+			{Field: "items[3].qty", Message: "Enter a quantity of at least 1."},
+		},
+	}, fmsg.GetFieldIssues(err))
+
+	a.Equal("Your order could not be placed.", fmsg.GetIssue(err))
+	a.Equal("create order", err.Error())
+}
+
+func TestGetFieldIssuesNone(t *testing.T) {
+	assert.Nil(t, fmsg.GetFieldIssues(fmsg.Wrap(errors.New("a problem"), "a", "A.")))
+}
+
+func TestGetIssuesFor(t *testing.T) {
+	a := assert.New(t)
+
+	err := errors.New("stripe: card_declined")
+	err = fmsg.WithOperatorDesc("charge failed",
+		"Your payment could not be processed.",
+		"The card was declined by the payment provider.",
+	)(err)
+	err = fmsg.With("checkout")(err)
+	err = fmsg.Wrap(err, "create order", "Your order could not be placed.")
+
+	a.Equal([]string{
+		"Your order could not be placed.",
+		"Your payment could not be processed.",
+	}, fmsg.GetIssuesFor(err, fmsg.EndUser))
+	a.Equal(fmsg.GetIssues(err), fmsg.GetIssuesFor(err, fmsg.EndUser))
+
+	a.Equal([]string{
+		"Your order could not be placed.",
+		"The card was declined by the payment provider.",
+	}, fmsg.GetIssuesFor(err, fmsg.Operator))
+
+	a.Equal([]string{
+		"create order",
+		"checkout",
+		"charge failed",
+	}, fmsg.GetIssuesFor(err, fmsg.Developer))
+
+	a.Equal("Your order could not be placed. The card was declined by the payment provider.", fmsg.GetIssueFor(err, fmsg.Operator))
+}
+
+func TestGetIssuesForDeveloperFallback(t *testing.T) {
+	err := fmsg.WithOperatorDesc("", "Try again.", "The queue is full.")(errors.New("a problem"))
+
+	assert.Equal(t, []string{"The queue is full."}, fmsg.GetIssuesFor(err, fmsg.Developer))
+}
+
+func TestGetDetails(t *testing.T) {
+	a := assert.New(t)
+
+	err := fmsg.WithDetail("user not found", fmsg.Detail{
+		Code:    "USR-404",
+		Message: "Cannot find the requested user.",
+		Link:    "https://example.com/docs/errors/USR-404",
+	})(errors.New("no rows"))
+	err = fmsg.With("get user")(err)
+	err = fmsg.Wrap(err, "load profile", "Your profile could not be loaded.")
+
+	a.Equal([]fmsg.Detail{
+		{Message: "Your profile could not be loaded."},
+		{Code: "USR-404", Message: "Cannot find the requested user.", Link: "https://example.com/docs/errors/USR-404"},
+	}, fmsg.GetDetails(err))
+
+	a.Equal("USR-404", fmsg.GetCode(err))
+	a.Equal("Your profile could not be loaded. Cannot find the requested user.", fmsg.GetIssue(err))
+}
+
+func TestGetCodeNone(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("", fmsg.GetCode(errors.New("a problem")))
+	a.Empty(fmsg.GetDetails(errors.New("a problem")))
+}
+
+func TestTemplateFromMeta(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := fctx.WithMeta(context.Background(), "order_id", "ord_123", "user_id", "usr_1")
+	ctx = fctx.WithSensitive(ctx, "email", "user@example.com")
+
+	err := fctx.Wrap(errors.New("carrier unavailable"), ctx)
+	err = fmsg.WithOperatorDesc("ship order",
+		"Order {order_id} could not be shipped.",
+		"Order {order_id} for {user_id} ({email}) could not be shipped.",
+	)(err)
+
+	a.Equal("Order {order_id} could not be shipped.", fmsg.GetIssue(err), "no keys are allowed by default")
+
+	fmsg.SetTemplateKeys("order_id", "email")
+	defer fmsg.SetTemplateKeys()
+
+	a.Equal("Order ord_123 could not be shipped.", fmsg.GetIssue(err))
+	a.Equal("Order ord_123 for {user_id} ({email}) could not be shipped.", fmsg.GetIssueFor(err, fmsg.Operator))
+	a.Equal("ship order", fmsg.GetIssueFor(err, fmsg.Developer))
+	a.Equal("Order ord_123 could not be shipped.", fmsg.GetDetails(err)[0].Message)
+
+	fctx.SetRedaction(fctx.Raw)
+	defer fctx.SetRedaction(fctx.Masked)
+
+	a.Equal("Order ord_123 for {user_id} ({email}) could not be shipped.", fmsg.GetIssueFor(err, fmsg.Operator), "sensitive metadata is never used, even when raw values are read")
+}
+
+func TestTemplateFromMetaWithCatalog(t *testing.T) {
+	a := assert.New(t)
+
+	c := fmsg.NewCatalog("en")
+	c.Add("en", "order.not_shipped", "Order {order_id} could not be shipped to {city}.")
+	fmsg.SetCatalog(c)
+	defer fmsg.SetCatalog(nil)
+
+	fmsg.SetTemplateKeys("order_id", "city")
+	defer fmsg.SetTemplateKeys()
+
+	ctx := fctx.WithMeta(context.Background(), "order_id", "ord_123", "city", "Leeds")
+	err := fctx.Wrap(errors.New("carrier unavailable"), ctx)
+	err = fmsg.WithKey("ship order", "order.not_shipped", fmsg.Args{"city": "London"})(err)
+
+	a.Equal("Order ord_123 could not be shipped to London.", fmsg.GetIssueLocalized(err, "en"), "arguments take precedence")
+}
@@ -95,3 +95,21 @@ failed to call function
 \s+.+fault/tests/test_callers.go:20
 `, fmt.Sprintf("%+v", err))
 }
+
+func TestFormatFunctions(t *testing.T) {
+	a := assert.New(t)
+
+	err := errorCaller(4)
+
+	a.Regexp(`^fault root cause error
+	github.com/Southclaws/fault/tests.rootCause
+		.+fault/tests/root.go:28
+	github.com/Southclaws/fault/tests.errorProducerFromRootCause
+		.+fault/tests/test_callers.go:29
+failed to call function
+	github.com/Southclaws/fault/tests.errorCallerFromMiddleOfChain
+		.+fault/tests/test_callers.go:20
+	github.com/Southclaws/fault/tests.errorCaller
+		.+fault/tests/test_callers.go:11
+$`, fmt.Sprintf("%+#v", err))
+}
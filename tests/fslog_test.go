@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fctx"
+	"github.com/Southclaws/fault/fmsg"
+	"github.com/Southclaws/fault/fslog"
+	"github.com/Southclaws/fault/ftag"
+	"github.com/stretchr/testify/assert"
+)
+
+func slogTestError() error {
+	ctx := fctx.WithMeta(context.Background(), "user_id", "123")
+	return fault.Wrap(errors.New("no rows"),
+		fctx.With(ctx),
+		ftag.With(ftag.NotFound),
+		fmsg.WithDesc("user not found", "The user could not be found."),
+	)
+}
+
+func TestFslogErrJSON(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetPathMode(fault.PathBase)
+	defer fault.SetPathMode(fault.PathAbsolute)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	logger.Error("request failed", fslog.Err(slogTestError()))
+
+	var entry map[string]any
+	a.NoError(json.Unmarshal(buf.Bytes(), &entry))
+
+	a.Equal(map[string]any{
+		"message": "user not found: no rows",
+		"chain": map[string]any{
+			"0": map[string]any{"message": "no rows"},
+			"1": map[string]any{"location": "fslog_test.go:21"},
+			"2": map[string]any{"location": "fslog_test.go:21"},
+			"3": map[string]any{"message": "user not found", "location": "fslog_test.go:21"},
+		},
+		"kind":  "NOT_FOUND",
+		"meta":  map[string]any{"user_id": "123"},
+		"issue": "The user could not be found.",
+	}, entry["error"])
+}
+
+func TestFslogErrText(t *testing.T) {
+	a := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+	logger.Error("request failed", fslog.Err(slogTestError()))
+
+	out := buf.String()
+	a.Contains(out, `error.message="user not found: no rows"`)
+	a.Contains(out, `error.chain.0.message="no rows"`)
+	a.NotContains(out, "<fctx>")
+	a.NotContains(out, "<ftag>")
+	a.Contains(out, `error.kind=NOT_FOUND`)
+	a.Contains(out, `error.meta.user_id=123`)
+	a.Contains(out, `error.issue="The user could not be found."`)
+}
+
+func TestFslogValueMinimal(t *testing.T) {
+	a := assert.New(t)
+
+	v := fslog.Value(errors.New("plain"))
+	attrs := v.Group()
+
+	a.Len(attrs, 2)
+	a.Equal("message", attrs[0].Key)
+	a.Equal("plain", attrs[0].Value.String())
+	a.Equal("chain", attrs[1].Key)
+}
+
+func TestFslogReplaceAttr(t *testing.T) {
+	a := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: fslog.ReplaceAttr,
+	}))
+	logger.Error("request failed", slog.Any("error", slogTestError()), slog.Int("status", 404))
+
+	var entry map[string]any
+	a.NoError(json.Unmarshal(buf.Bytes(), &entry))
+
+	e, ok := entry["error"].(map[string]any)
+	a.True(ok)
+	a.Equal("user not found: no rows", e["message"])
+	a.Equal("NOT_FOUND", e["kind"])
+	a.Equal(float64(404), entry["status"])
+}
+
+func fslogHandlerOutput(t *testing.T, opts *fslog.HandlerOptions, fn func(ctx context.Context, l *slog.Logger)) map[string]any {
+	buf := &bytes.Buffer{}
+	logger := slog.New(fslog.NewHandler(slog.NewJSONHandler(buf, nil), opts))
+
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc", "user_id", "123")
+	fn(ctx, logger)
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry
+}
+
+func TestFslogHandler(t *testing.T) {
+	a := assert.New(t)
+
+	entry := fslogHandlerOutput(t, nil, func(ctx context.Context, l *slog.Logger) {
+		l.InfoContext(ctx, "request", slog.Int("status", 200))
+	})
+
+	a.Equal("abc", entry["request_id"])
+	a.Equal("123", entry["user_id"])
+	a.Equal(float64(200), entry["status"])
+}
+
+func TestFslogHandlerNoContext(t *testing.T) {
+	a := assert.New(t)
+
+	entry := fslogHandlerOutput(t, nil, func(ctx context.Context, l *slog.Logger) {
+		l.Info("request")
+	})
+
+	a.NotContains(entry, "request_id")
+	a.Equal("request", entry["msg"])
+}
+
+func TestFslogHandlerConflict(t *testing.T) {
+	a := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(fslog.NewHandler(slog.NewTextHandler(buf, nil), nil))
+	ctx := fctx.WithMeta(context.Background(), "request_id", "abc", "user_id", "123")
+
+	logger.With("user_id", "from-with").InfoContext(ctx, "request", "request_id", "from-call")
+
+	out := buf.String()
+	a.Contains(out, "request_id=from-call")
+	a.Contains(out, "user_id=from-with")
+	a.NotContains(out, "request_id=abc")
+	a.NotContains(out, "user_id=123")
+}
+
+func TestFslogHandlerGroupOption(t *testing.T) {
+	a := assert.New(t)
+
+	entry := fslogHandlerOutput(t, &fslog.HandlerOptions{Group: "meta"}, func(ctx context.Context, l *slog.Logger) {
+		l.InfoContext(ctx, "request", "request_id", "from-call")
+	})
+
+	a.Equal("from-call", entry["request_id"])
+	a.Equal(map[string]any{"request_id": "abc", "user_id": "123"}, entry["meta"])
+}
+
+func TestFslogHandlerWithGroup(t *testing.T) {
+	a := assert.New(t)
+
+	entry := fslogHandlerOutput(t, nil, func(ctx context.Context, l *slog.Logger) {
+		l.With("user_id", "outside").WithGroup("req").InfoContext(ctx, "request", "status", 200)
+	})
+
+	a.Equal("outside", entry["user_id"])
+	a.Equal(map[string]any{"request_id": "abc", "user_id": "123", "status": float64(200)}, entry["req"])
+}
+
+func TestFslogTypedMeta(t *testing.T) {
+	a := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(fslog.NewHandler(slog.NewJSONHandler(buf, nil), nil))
+
+	ctx := fctx.WithAttrs(context.Background(), slog.Int("retries", 3), slog.Bool("admin", true))
+	err := fault.Wrap(errors.New("failed"), fctx.With(ctx))
+	logger.ErrorContext(ctx, "job failed", fslog.Err(err))
+
+	var entry map[string]any
+	a.NoError(json.Unmarshal(buf.Bytes(), &entry))
+
+	a.Equal(float64(3), entry["retries"])
+	a.Equal(true, entry["admin"])
+	a.Equal(map[string]any{"retries": float64(3), "admin": true}, entry["error"].(map[string]any)["meta"])
+}
+
+func TestFslogMultiValuedMeta(t *testing.T) {
+	a := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(fslog.NewHandler(slog.NewJSONHandler(buf, nil), nil))
+
+	ctx := fctx.Append(context.Background(), "shard", "eu-1", "shard", "eu-2")
+	logger.InfoContext(ctx, "queried")
+
+	var entry map[string]any
+	a.NoError(json.Unmarshal(buf.Bytes(), &entry))
+
+	a.Equal([]any{"eu-1", "eu-2"}, entry["shard"])
+}
+
+func TestFslogRestoredMeta(t *testing.T) {
+	a := assert.New(t)
+
+	ctx, rerr := fctx.Restore(context.Background(), []byte(`{"request_id":"abc"}`))
+	a.NoError(rerr)
+
+	v := fslog.Value(fault.Wrap(errors.New("job failed"), fctx.With(ctx)))
+
+	restored := false
+	for _, attr := range v.Group() {
+		if attr.Key == "restored" {
+			restored = attr.Value.Bool()
+		}
+	}
+	a.True(restored)
+}
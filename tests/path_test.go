@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Southclaws/fault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathModeAbsolute(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(4))
+
+	a.Regexp(`^/.+/fault/tests/root.go:28$`, chain[0].Location)
+}
+
+func TestPathModeModule(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetPathMode(fault.PathModule)
+	defer fault.SetPathMode(fault.PathAbsolute)
+
+	err := errorCaller(4)
+	chain := fault.Flatten(err)
+
+	a.Equal("tests/root.go:28", chain[0].Location)
+	a.Equal("tests/root.go", chain[0].File)
+	a.Equal("tests/test_callers.go:29", chain[1].Location)
+	a.Equal("tests/test_callers.go:20", chain[2].Location)
+	a.Equal("tests/test_callers.go:11", chain[3].Location)
+
+	a.Equal(`fault root cause error
+	tests/root.go:28
+	tests/test_callers.go:29
+failed to call function
+	tests/test_callers.go:20
+	tests/test_callers.go:11
+`, fmt.Sprintf("%+v", err))
+}
+
+func TestPathModeModuleStack(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetPathMode(fault.PathModule)
+	defer fault.SetPathMode(fault.PathAbsolute)
+	fault.SetStackDepth(32)
+	defer fault.SetStackDepth(0)
+
+	chain := fault.Flatten(errorCaller(4))
+	stack := chain[len(chain)-1].Stack()
+
+	a.Equal("tests/test_callers.go", stack[0].File)
+	a.Equal("testing/testing.go", stack[2].File, "files outside of the module use the package path")
+}
+
+func TestPathModeBase(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetPathMode(fault.PathBase)
+	defer fault.SetPathMode(fault.PathAbsolute)
+
+	chain := fault.Flatten(errorCaller(4))
+
+	a.Equal("root.go:28", chain[0].Location)
+	a.Equal("root.go", chain[0].File)
+	a.Equal("test_callers.go:11", chain[3].Location)
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Southclaws/fault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlattenStackDisabled(t *testing.T) {
+	a := assert.New(t)
+
+	chain := fault.Flatten(errorCaller(4))
+
+	for _, s := range chain {
+		a.Nil(s.Stack())
+	}
+}
+
+func TestFlattenStack(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetStackDepth(32)
+	defer fault.SetStackDepth(0)
+
+	err := errorCaller(4)
+	chain := fault.Flatten(err)
+
+	a.Len(chain, 4)
+
+	e0 := chain[0]
+	a.Equal("fault root cause error", e0.Message)
+	a.Len(e0.Stack(), 2)
+	a.Contains(e0.Stack()[0].String(), "root.go:28")
+	a.Equal(e0.Location, e0.Stack()[0].String())
+	a.Equal("github.com/Southclaws/fault/tests.rootCause", e0.Stack()[0].Function)
+	a.Contains(e0.Stack()[1].File, "test_callers.go")
+	a.Equal(27, e0.Stack()[1].Line)
+
+	e1 := chain[1]
+	a.Len(e1.Stack(), 2)
+	a.Contains(e1.Stack()[0].String(), "test_callers.go:29")
+	a.Contains(e1.Stack()[1].String(), "test_callers.go:18")
+
+	e2 := chain[2]
+	a.Equal("failed to call function", e2.Message)
+	a.Len(e2.Stack(), 2)
+	a.Contains(e2.Stack()[0].String(), "test_callers.go:20")
+	a.Contains(e2.Stack()[1].String(), "test_callers.go:9")
+
+	e3 := chain[3]
+	a.Contains(e3.Stack()[0].String(), "test_callers.go:11")
+	a.Equal("github.com/Southclaws/fault/tests.TestFlattenStack", e3.Stack()[1].Function)
+}
+
+func TestFlattenStackDepth(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetStackDepth(2)
+	defer fault.SetStackDepth(0)
+
+	chain := fault.Flatten(errorCaller(4))
+
+	for _, s := range chain {
+		a.LessOrEqual(len(s.Stack()), 2)
+		a.NotEmpty(s.Stack())
+	}
+}
+
+func TestFormatStack(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetStackDepth(32)
+	defer fault.SetStackDepth(0)
+
+	err := errorCaller(4)
+
+	a.Regexp(`^fault root cause error
+\s+.+fault/tests/root.go:28
+\s+.+fault/tests/test_callers.go:27
+\s+.+fault/tests/test_callers.go:29
+\s+.+fault/tests/test_callers.go:18
+failed to call function
+\s+.+fault/tests/test_callers.go:20
+\s+.+fault/tests/test_callers.go:9
+\s+.+fault/tests/test_callers.go:11
+\s+.+fault/tests/stack_test.go:\d+
+`, fmt.Sprintf("%+v", err))
+}
+
+func TestStepComparable(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetStackDepth(32)
+	defer fault.SetStackDepth(0)
+
+	chain := fault.Flatten(errorCaller(4))
+
+	seen := map[fault.Step]bool{}
+	for _, s := range chain {
+		seen[s] = true
+	}
+
+	a.Len(seen, len(chain))
+	a.True(chain[0] == chain[0])
+}
+
+func TestStepStackJSON(t *testing.T) {
+	a := assert.New(t)
+
+	fault.SetStackDepth(32)
+	defer fault.SetStackDepth(0)
+
+	chain := fault.Flatten(errorCaller(4))
+
+	b, err := json.Marshal(chain)
+	a.NoError(err)
+
+	var decoded fault.Chain
+	a.NoError(json.Unmarshal(b, &decoded))
+
+	a.Len(decoded, len(chain))
+	for i := range chain {
+		a.Equal(chain[i].Location, decoded[i].Location)
+		a.Equal(chain[i].Stack(), decoded[i].Stack())
+	}
+
+	fault.SetStackDepth(0)
+
+	b, err = json.Marshal(fault.Flatten(fault.New("no stack")))
+	a.NoError(err)
+	a.NotContains(string(b), `"stack"`)
+}
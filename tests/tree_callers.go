@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Southclaws/fault"
+	"github.com/Southclaws/fault/fmsg"
+)
+
+func batchCaller() error {
+	err := batchProducer()
+	if err != nil {
+		return fault.Wrap(err, fmsg.With("batch failed"))
+	}
+
+	return nil
+}
+
+func batchProducer() error {
+	return errors.Join(
+		fault.Wrap(errors.New("item 1 failed")),
+		fault.Wrap(fault.New("item 2 failed"), fmsg.With("retry exhausted")),
+	)
+}
+
+func errorfBatchCaller() error {
+	err := errorfBatchProducer()
+	if err != nil {
+		return fault.Wrap(err, fmsg.With("batch failed"))
+	}
+
+	return nil
+}
+
+func errorfBatchProducer() error {
+	return fmt.Errorf("items failed: %w, %w",
+		fault.New("item 1 failed"),
+		fault.Wrap(errors.New("item 2 failed")),
+	)
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Southclaws/fault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlattenTree(t *testing.T) {
+	a := assert.New(t)
+
+	err := batchCaller()
+	tree := fault.FlattenTree(err)
+
+	a.Len(tree.Chain, 1)
+	a.Equal("batch failed", tree.Chain[0].Message)
+	a.Contains(tree.Chain[0].Location, "tree_callers.go:14")
+
+	a.Len(tree.Branches, 2)
+
+	b0 := tree.Branches[0]
+	a.Empty(b0.Branches)
+	a.Len(b0.Chain, 2)
+	a.Equal("item 1 failed", b0.Chain[0].Message)
+	a.Empty(b0.Chain[0].Location)
+	a.Empty(b0.Chain[1].Message)
+	a.Contains(b0.Chain[1].Location, "tree_callers.go:22")
+
+	b1 := tree.Branches[1]
+	a.Empty(b1.Branches)
+	a.Len(b1.Chain, 2)
+	a.Equal("item 2 failed", b1.Chain[0].Message)
+	a.Contains(b1.Chain[0].Location, "tree_callers.go:23")
+	a.Equal("retry exhausted", b1.Chain[1].Message)
+	a.Contains(b1.Chain[1].Location, "tree_callers.go:23")
+}
+
+func TestFlattenTreeNoBranches(t *testing.T) {
+	a := assert.New(t)
+
+	err := errorCaller(1)
+	tree := fault.FlattenTree(err)
+
+	a.Equal(fault.Flatten(err), tree.Chain)
+	a.Empty(tree.Branches)
+}
+
+func TestFlattenTreeNil(t *testing.T) {
+	assert.Nil(t, fault.FlattenTree(nil))
+}
+
+func TestFlattenJoinedSingleStep(t *testing.T) {
+	a := assert.New(t)
+
+	err := batchCaller()
+	chain := fault.Flatten(err)
+
+	a.Len(chain, 2)
+	a.Equal("item 1 failed\nretry exhausted: item 2 failed", chain[0].Message)
+	a.Equal("batch failed", chain[1].Message)
+}
+
+func TestFormatTree(t *testing.T) {
+	a := assert.New(t)
+
+	err := batchCaller()
+
+	a.Equal("batch failed: item 1 failed\nretry exhausted: item 2 failed", err.Error())
+	a.Regexp(`^\[1/2\]
+	item 1 failed
+		.+fault/tests/tree_callers.go:22
+\[2/2\]
+	item 2 failed
+		.+fault/tests/tree_callers.go:23
+	retry exhausted
+		.+fault/tests/tree_callers.go:23
+batch failed
+	.+fault/tests/tree_callers.go:14
+$`, fmt.Sprintf("%+v", err))
+}
+
+func TestFlattenTreeErrorsJoin(t *testing.T) {
+	a := assert.New(t)
+
+	err := errors.Join(errors.New("a failed"), errors.New("b failed"))
+	tree := fault.FlattenTree(err)
+
+	// the joined message is represented by the branches so it's removed.
+	a.Empty(tree.Chain)
+	a.Len(tree.Branches, 2)
+	a.Equal("a failed", tree.Branches[0].Chain[0].Message)
+	a.Equal("b failed", tree.Branches[1].Chain[0].Message)
+}
+
+func TestFlattenTreeErrorf(t *testing.T) {
+	a := assert.New(t)
+
+	err := errorfBatchCaller()
+	tree := fault.FlattenTree(err)
+
+	// fmt.Errorf adds its own text to the messages of the branches so the
+	// message of the branching error is kept.
+	a.Len(tree.Chain, 2)
+	a.Equal("items failed: item 1 failed, item 2 failed", tree.Chain[0].Message)
+	a.Equal("batch failed", tree.Chain[1].Message)
+	a.Contains(tree.Chain[1].Location, "tree_callers.go:30")
+
+	a.Len(tree.Branches, 2)
+
+	b0 := tree.Branches[0]
+	a.Len(b0.Chain, 1)
+	a.Equal("item 1 failed", b0.Chain[0].Message)
+	a.Contains(b0.Chain[0].Location, "tree_callers.go:38")
+
+	b1 := tree.Branches[1]
+	a.Len(b1.Chain, 2)
+	a.Equal("item 2 failed", b1.Chain[0].Message)
+	a.Empty(b1.Chain[0].Location)
+	a.Contains(b1.Chain[1].Location, "tree_callers.go:39")
+}
+
+func TestFlattenTreeErrorfNewlines(t *testing.T) {
+	a := assert.New(t)
+
+	err := fmt.Errorf("%w\n%w", errors.New("a failed"), errors.New("b failed"))
+	tree := fault.FlattenTree(err)
+
+	a.Empty(tree.Chain)
+	a.Len(tree.Branches, 2)
+}